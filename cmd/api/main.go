@@ -0,0 +1,35 @@
+package main
+
+import (
+	"cars/pkg/api"
+	"cars/pkg/catalog"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+)
+
+// Serves the catalog API, replacing the NodeJS server in api/main.js.
+// Like the NodeJS server, it listens on the port in the PORT environment variable, or 3000.
+func main() {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "3000"
+	}
+
+	addr := flag.String("addr", ":"+port, "address where the API listens")
+	dataFile := flag.String("data-file", "api/data.json", "JSON file with the catalog")
+	imagesDir := flag.String("images-dir", "api/img", "directory with the car images")
+	flag.Parse()
+
+	data, err := catalog.ReadDataFile(*dataFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Server is running on http://localhost%s\n", *addr)
+	if err := http.ListenAndServe(*addr, api.NewHandler(data, *imagesDir)); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -1,34 +1,180 @@
 package main
 
 import (
+	"cars/pkg/accounts"
+	"cars/pkg/api"
+	"cars/pkg/catalog"
+	"cars/pkg/client"
+	"cars/pkg/config"
 	"cars/pkg/helpers"
+	"cars/pkg/reviews"
 	"cars/pkg/routes"
+	"cars/pkg/session"
+	"cars/pkg/shares"
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
-	//	We populate the variables FavouritesMap and ComparisonMap with
-	//	a list of all the ID cars and a boolean value initiated as false.
-	//	This maps will help us keep track of the items liked, and items selected to
-	//	be compared.
+	//	Read the settings from the flags and the environment variables.
+	settings, err := config.ParseSettings(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	//	The handlers read the cars, manufacturers and categories from this cache.
+	//	It is filled by InitVariable and refreshed in the background every CacheTTL.
+	var source catalog.Source
+	var imagesDir string
+	var apiHandler http.Handler
+
+	switch settings.Source {
+	case "file":
+		fmt.Printf("Reading the catalog from %s...\n", settings.DataFile)
+		source = catalog.NewFileSource(settings.DataFile, routes.ImagesPath)
+		imagesDir = settings.ImagesDir
+	case "embedded":
+		//	The catalog API runs inside this process, so no NodeJS server is needed.
+		fmt.Printf("Serving the catalog API from %s...\n", settings.DataFile)
+		data, err := catalog.ReadDataFile(settings.DataFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		source = catalog.NewMemorySource(data, "/api/images/")
+		apiHandler = api.NewHandler(data, settings.ImagesDir)
+	default:
+		fmt.Printf("Reading the catalog from the API at %s...\n", settings.APIURL)
+		source = newClient(settings)
+	}
+	helpers.Catalog = catalog.New(source, settings.CacheTTL)
+
+	//	The server stops gracefully on Ctrl+C or SIGTERM, so the last changes are saved.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	//	Registered users keep their favourites and comparisons in their account,
+	//	so they follow them across browsers.
+	helpers.Accounts = accounts.NewMemoryStore()
+	if settings.UsersFile != "" {
+		helpers.Accounts, err = accounts.NewFileStore(settings.UsersFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer func() {
+			if err := helpers.Accounts.Close(); err != nil {
+				log.Printf("Error saving accounts: %v", err)
+			}
+		}()
+	}
+
+	//	Comparisons saved with "Save & share" are reached by anyone with their code.
+	helpers.Shares = shares.NewMemoryStore()
+	if settings.SharesFile != "" {
+		helpers.Shares, err = shares.NewFileStore(settings.SharesFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer func() {
+			if err := helpers.Shares.Close(); err != nil {
+				log.Printf("Error saving shared comparisons: %v", err)
+			}
+		}()
+	}
+
+	//	Reviews are public once one of the moderators approves them.
+	//	Their usernames cannot be registered, so nobody can take the name of a moderator without an account.
+	helpers.Moderators = settings.Moderators
+	helpers.Accounts.Reserve(settings.Moderators)
+	for _, moderator := range settings.Moderators {
+		if _, ok := helpers.Accounts.Get(moderator); !ok {
+			log.Printf("Warning: the moderator %q has no account. Register it while it is not in -moderators.", moderator)
+		}
+	}
+	helpers.Reviews = reviews.NewMemoryStore()
+	if settings.ReviewsFile != "" {
+		helpers.Reviews, err = reviews.NewFileStore(settings.ReviewsFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer func() {
+			if err := helpers.Reviews.Close(); err != nil {
+				log.Printf("Error saving reviews: %v", err)
+			}
+		}()
+	}
+
+	//	Each visitor gets a session, identified by a cookie, that keeps its favourites,
+	//	the cars selected to be compared, the last comparison and the filters.
+	//	Sessions are saved to StateFile, so they survive restarts.
+	var store session.Store = session.NewMemoryStore()
+	if settings.StateFile != "" {
+		fileStore, err := session.NewFileStore(settings.StateFile, helpers.Accounts.State)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer func() {
+			if err := fileStore.Close(); err != nil {
+				log.Printf("Error saving sessions: %v", err)
+			}
+		}()
+		store = fileStore
+	}
+	sessions := session.NewManager(store, settings.SessionTTL)
+	sessions.StartCleanup(ctx, time.Hour)
 
 	// Get the router from the routes package
-	router := routes.Routes()
+	router := routes.Routes(imagesDir, sessions)
+	if apiHandler != nil {
+		router.Handle("/api", apiHandler)
+		router.Handle("/api/", apiHandler)
+	}
 
 	errChannel := make(chan error, 1)
-	defer close(errChannel)
 
 	go helpers.InitVariable(errChannel)
-	err := <-errChannel
+	err = <-errChannel
 	if err != nil {
 		fmt.Println("Error initiating program.")
 		log.Fatal(err)
 	}
+	helpers.Catalog.Start(ctx)
 
-	fmt.Println("Running Server in 8080...")
-	if err := http.ListenAndServe(":8080", router); err != nil {
-		log.Fatal(err)
+	server := &http.Server{Addr: settings.Addr, Handler: router}
+	go func() {
+		<-ctx.Done()
+		fmt.Println("Stopping Server...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Printf("Running Server in %s...\n", settings.Addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Print(err)
+	}
+}
+
+// Creates the client of the catalog API configured in the settings.
+func newClient(settings config.Settings) *client.Client {
+	//	Failed requests are retried, and the breaker stops calling the API while it is down.
+	clientOptions := []client.Option{
+		client.WithTimeout(settings.APITimeout),
+		client.WithUserAgent(settings.UserAgent),
+		client.WithRetry(client.RetryPolicy{
+			MaxRetries: settings.APIRetries,
+			BaseDelay:  settings.APIRetryDelay,
+			MaxDelay:   settings.APIRetryMaxDelay,
+		}),
+	}
+	if settings.BreakerThreshold > 0 {
+		clientOptions = append(clientOptions, client.WithBreaker(client.NewBreaker(settings.BreakerThreshold, settings.BreakerCooldown)))
 	}
+	return client.New(settings.APIURL, clientOptions...)
 }
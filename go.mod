@@ -1,3 +1,5 @@
 module cars
 
 go 1.22.1
+
+require golang.org/x/crypto v0.33.0
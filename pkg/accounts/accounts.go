@@ -0,0 +1,221 @@
+package accounts
+
+import (
+	"cars/pkg/state"
+	"cars/pkg/storage"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Errors returned by Register and Authenticate.
+var (
+	ErrInvalidUsername    = errors.New("the username must have 3 to 32 letters, numbers, dots, dashes or underscores")
+	ErrWeakPassword       = errors.New("the password must have at least 8 characters")
+	ErrLongPassword       = errors.New("the password must have at most 72 bytes, which is 72 letters without accents")
+	ErrUsernameTaken      = errors.New("that username is already taken")
+	ErrInvalidCredentials = errors.New("wrong username or password")
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-z0-9._-]{3,32}$`)
+
+// MinPasswordLength is the minimum number of characters of a password.
+const MinPasswordLength = 8
+
+// User is the public information of an account.
+type User struct {
+	Username string
+	Created  time.Time
+}
+
+// account is a user account as kept in memory.
+type account struct {
+	User
+	passwordHash string
+	state        *state.Store
+}
+
+// fileVersion is the version of the format of the accounts file.
+const fileVersion = 1
+
+// accountsFile is the document written to disk.
+type accountsFile struct {
+	Version int             `json:"version"`
+	Users   []storedAccount `json:"users"`
+}
+
+type storedAccount struct {
+	Username     string         `json:"username"`
+	PasswordHash string         `json:"passwordHash"`
+	Created      time.Time      `json:"created"`
+	State        state.Snapshot `json:"state"`
+}
+
+// Store keeps the user accounts and the state (favourites, comparisons, filters) of each of them.
+// It is safe for concurrent use.
+type Store struct {
+	mu       sync.RWMutex
+	accounts map[string]*account
+	//	Usernames that cannot be registered, like the moderators.
+	reserved map[string]bool
+
+	doc *storage.Document
+}
+
+// Creates a Store that keeps the accounts only in memory.
+func NewMemoryStore() *Store {
+	return &Store{accounts: make(map[string]*account), reserved: make(map[string]bool)}
+}
+
+// Loads the accounts stored in the file at path, if it exists, and starts writing the changes to it.
+// The favourites and comparisons of each account are written with it.
+func NewFileStore(path string) (*Store, error) {
+	var stored accountsFile
+	doc, _, err := storage.LoadDocument(path, "accounts", fileVersion, &stored)
+	if err != nil {
+		return nil, err
+	}
+
+	s := NewMemoryStore()
+	for _, storedAccount := range stored.Users {
+		s.accounts[storedAccount.Username] = &account{
+			User:         User{Username: storedAccount.Username, Created: storedAccount.Created},
+			passwordHash: storedAccount.PasswordHash,
+			state:        state.FromSnapshot(storedAccount.State),
+		}
+	}
+
+	s.doc = doc
+	s.doc.Start(s.snapshot)
+	for _, account := range s.accounts {
+		account.state.OnChange(s.doc.MarkChanged)
+	}
+	return s, nil
+}
+
+// Usernames are not case sensitive: they are kept in lower case.
+func normalize(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// Creates a new account. The password is only kept hashed.
+func (s *Store) Register(username, password string) (User, error) {
+	username = normalize(username)
+	if !usernamePattern.MatchString(username) {
+		return User{}, ErrInvalidUsername
+	}
+	if len([]rune(password)) < MinPasswordLength {
+		return User{}, ErrWeakPassword
+	}
+	if len(password) > MaxPasswordLength {
+		return User{}, ErrLongPassword
+	}
+
+	//	Hashing is slow on purpose, so it is done before taking the lock.
+	hash, err := HashPassword(password)
+	if err != nil {
+		return User{}, err
+	}
+
+	s.mu.Lock()
+	if _, ok := s.accounts[username]; ok || s.reserved[username] {
+		s.mu.Unlock()
+		return User{}, ErrUsernameTaken
+	}
+	account := &account{
+		User:         User{Username: username, Created: time.Now()},
+		passwordHash: hash,
+		state:        state.New(),
+	}
+	s.accounts[username] = account
+	s.mu.Unlock()
+
+	if s.doc != nil {
+		account.state.OnChange(s.doc.MarkChanged)
+		s.doc.MarkChanged()
+	}
+	return account.User, nil
+}
+
+// Stops the usernames given from being registered. The accounts that already exist are kept.
+func (s *Store) Reserve(usernames []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, username := range usernames {
+		s.reserved[normalize(username)] = true
+	}
+}
+
+// dummyHash is checked when the username does not exist, so that the response
+// takes the same time and does not tell which usernames exist.
+var dummyHash, _ = HashPassword("not a real password")
+
+// Returns the user if the password is right, and ErrInvalidCredentials otherwise.
+func (s *Store) Authenticate(username, password string) (User, error) {
+	s.mu.RLock()
+	account, ok := s.accounts[normalize(username)]
+	s.mu.RUnlock()
+
+	if !ok {
+		CheckPassword(dummyHash, password)
+		return User{}, ErrInvalidCredentials
+	}
+	match, err := CheckPassword(account.passwordHash, password)
+	if err != nil {
+		return User{}, err
+	}
+	if !match {
+		return User{}, ErrInvalidCredentials
+	}
+	return account.User, nil
+}
+
+// Returns the user with the username given, and false if it does not exist.
+func (s *Store) Get(username string) (User, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	account, ok := s.accounts[normalize(username)]
+	if !ok {
+		return User{}, false
+	}
+	return account.User, true
+}
+
+// Returns the state of the account, or nil if it does not exist.
+func (s *Store) State(username string) *state.Store {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	account, ok := s.accounts[normalize(username)]
+	if !ok {
+		return nil
+	}
+	return account.state
+}
+
+// Closes the file of the accounts.
+func (s *Store) Close() error {
+	return s.doc.Close()
+}
+
+// Returns the document to write with all the accounts, ordered by username.
+func (s *Store) snapshot() any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored := accountsFile{Version: fileVersion, Users: []storedAccount{}}
+	for _, account := range s.accounts {
+		stored.Users = append(stored.Users, storedAccount{
+			Username:     account.Username,
+			PasswordHash: account.passwordHash,
+			Created:      account.Created,
+			State:        account.state.Snapshot(),
+		})
+	}
+	sort.Slice(stored.Users, func(i, j int) bool {
+		return stored.Users[i].Username < stored.Users[j].Username
+	})
+	return stored
+}
@@ -0,0 +1,37 @@
+package accounts
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// hashCost is the bcrypt cost of the password hashing. Each step doubles the time taken,
+// and 12 follows the OWASP recommendation.
+const hashCost = 12
+
+// MaxPasswordLength is the maximum number of bytes of a password: bcrypt ignores anything after them.
+const MaxPasswordLength = 72
+
+// Returns the bcrypt hash of the password, with its cost and salt in it.
+// The salt is random, so hashing the same password twice gives different results.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), hashCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Reports whether the password matches the hash created by HashPassword.
+// The comparison takes constant time, so the time taken does not tell how much of the hash matched.
+func CheckPassword(hash, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"cars/pkg/catalog"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// server answers the catalog endpoints with the data loaded at start up.
+type server struct {
+	data catalog.Data
+}
+
+// Returns a handler that serves the catalog with the same endpoints, JSON bodies and 404 messages
+// as the NodeJS API in api/main.js. The images are served from imagesDir under /api/images/.
+func NewHandler(data catalog.Data, imagesDir string) http.Handler {
+	s := &server{data: data}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api", s.index)
+	mux.HandleFunc("GET /api/{$}", s.index)
+
+	mux.HandleFunc("GET /api/models", s.cars)
+	mux.HandleFunc("GET /api/models/{$}", s.cars)
+	mux.HandleFunc("GET /api/models/{id}", s.car)
+
+	mux.HandleFunc("GET /api/categories", s.categories)
+	mux.HandleFunc("GET /api/categories/{$}", s.categories)
+	mux.HandleFunc("GET /api/categories/{id}", s.category)
+
+	mux.HandleFunc("GET /api/manufacturers", s.manufacturers)
+	mux.HandleFunc("GET /api/manufacturers/{$}", s.manufacturers)
+	mux.HandleFunc("GET /api/manufacturers/{id}", s.manufacturer)
+
+	imagesServer := http.FileServer(http.Dir(imagesDir))
+	mux.Handle("GET /api/images/", http.StripPrefix("/api/images", imagesServer))
+
+	return mux
+}
+
+// Lists the endpoints of the API.
+func (s *server) index(w http.ResponseWriter, r *http.Request) {
+	//	A struct instead of a map keeps the keys in the same order as the NodeJS API.
+	writeJSON(w, http.StatusOK, struct {
+		Models        string `json:"models"`
+		Categories    string `json:"categories"`
+		Manufacturers string `json:"manufacturers"`
+	}{
+		Models:        "/api/models",
+		Categories:    "/api/categories",
+		Manufacturers: "/api/manufacturers",
+	})
+}
+
+// Responds with all the car models.
+func (s *server) cars(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.data.Cars)
+}
+
+// Responds with the car model of the ID in the path, or 404.
+func (s *server) car(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(r.PathValue("id"))
+	if ok {
+		for _, car := range s.data.Cars {
+			if car.Id == id {
+				writeJSON(w, http.StatusOK, car)
+				return
+			}
+		}
+	}
+	writeNotFound(w, "Car model not found")
+}
+
+// Responds with all the categories.
+func (s *server) categories(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.data.Categories)
+}
+
+// Responds with the category of the ID in the path, or 404.
+func (s *server) category(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(r.PathValue("id"))
+	if ok {
+		for _, category := range s.data.Categories {
+			if category.Id == id {
+				writeJSON(w, http.StatusOK, category)
+				return
+			}
+		}
+	}
+	writeNotFound(w, "Category not found")
+}
+
+// Responds with all the manufacturers.
+func (s *server) manufacturers(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.data.Manufacturers)
+}
+
+// Responds with the manufacturer of the ID in the path, or 404.
+func (s *server) manufacturer(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(r.PathValue("id"))
+	if ok {
+		for _, manufacturer := range s.data.Manufacturers {
+			if manufacturer.Id == id {
+				writeJSON(w, http.StatusOK, manufacturer)
+				return
+			}
+		}
+	}
+	writeNotFound(w, "Manufacturer not found")
+}
+
+// Parses an ID the way JavaScript parseInt does: leading spaces and a sign are allowed,
+// and it stops at the first character that is not a digit ("3abc" is 3).
+// It returns false when there are no digits at all.
+func parseID(value string) (int, bool) {
+	value = strings.TrimLeft(value, " \t\n\r")
+	negative := false
+	if value != "" && (value[0] == '-' || value[0] == '+') {
+		negative = value[0] == '-'
+		value = value[1:]
+	}
+
+	id, digits := 0, 0
+	for _, char := range value {
+		if char < '0' || char > '9' {
+			break
+		}
+		id = id*10 + int(char-'0')
+		digits++
+	}
+	if digits == 0 {
+		return 0, false
+	}
+	if negative {
+		id = -id
+	}
+	return id, true
+}
+
+// Writes v as JSON with the same format as Express res.json: compact, without HTML escaping.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	var body strings.Builder
+	encoder := json.NewEncoder(&body)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		fmt.Printf("Error encoding JSON response: %v\n", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	//	Encode adds a new line that Express does not send.
+	fmt.Fprint(w, strings.TrimSuffix(body.String(), "\n"))
+}
+
+// Writes the 404 body used by the NodeJS API: {"message": "..."}.
+func writeNotFound(w http.ResponseWriter, message string) {
+	writeJSON(w, http.StatusNotFound, struct {
+		Message string `json:"message"`
+	}{message})
+}
@@ -0,0 +1,242 @@
+package catalog
+
+import (
+	"cars/pkg/client"
+	"cars/pkg/models"
+	"cars/pkg/search"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Cache keeps in memory a copy of the cars, manufacturers and categories of a Source.
+// Reads are served from memory, and the copy is refreshed in the background every TTL.
+// When a refresh fails the last good copy is kept and served, and the cache is marked as stale
+// until a refresh succeeds again. Every successful refresh builds again the search index.
+type Cache struct {
+	source Source
+	ttl    time.Duration
+
+	mu            sync.RWMutex
+	cars          []models.Car
+	manufacturers []models.Manufacturers
+	categories    []models.Categories
+	index         *search.Index
+	refreshedAt   time.Time
+	lastErr       error
+}
+
+// Creates an empty Cache for the source given. Call Refresh or Start to fill it.
+func New(source Source, ttl time.Duration) *Cache {
+	return &Cache{
+		source: source,
+		ttl:    ttl,
+	}
+}
+
+// Returns the URL the browser uses to load the image of a car.
+func (c *Cache) ImageURL(image string) string {
+	return c.source.ImageURL(image)
+}
+
+// Reads cars, manufacturers and categories from the source, at once when it is a DataSource.
+// The cached data is only replaced when the three of them are fetched successfully.
+func (c *Cache) Refresh() error {
+	var data Data
+	var err error
+	if source, ok := c.source.(DataSource); ok {
+		data, err = source.Data()
+	} else {
+		data, err = c.fetch()
+	}
+
+	//	The index is built before taking the lock, so searches are not stopped meanwhile.
+	var index *search.Index
+	if err == nil {
+		index = search.NewIndex(data.Cars, data.Manufacturers, data.Categories)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err != nil {
+		if c.lastErr == nil && !c.refreshedAt.IsZero() {
+			log.Printf("Catalog refresh failed, serving data from %s: %v", c.refreshedAt.Format(time.RFC3339), err)
+		}
+		c.lastErr = err
+		return err
+	}
+
+	if c.lastErr != nil && !c.refreshedAt.IsZero() {
+		log.Println("Catalog refreshed again, data is up to date.")
+	}
+	c.cars = data.Cars
+	c.manufacturers = data.Manufacturers
+	c.categories = data.Categories
+	c.index = index
+	c.refreshedAt = time.Now()
+	c.lastErr = nil
+	return nil
+}
+
+// Reads cars, manufacturers and categories from the source at the same time.
+func (c *Cache) fetch() (Data, error) {
+	var wg sync.WaitGroup
+	var cars []models.Car
+	var manufacturers []models.Manufacturers
+	var categories []models.Categories
+	var carsErr, manufacturersErr, categoriesErr error
+
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		cars, carsErr = c.source.Cars()
+	}()
+	go func() {
+		defer wg.Done()
+		manufacturers, manufacturersErr = c.source.Manufacturers()
+	}()
+	go func() {
+		defer wg.Done()
+		categories, categoriesErr = c.source.Categories()
+	}()
+	wg.Wait()
+
+	if err := errors.Join(carsErr, manufacturersErr, categoriesErr); err != nil {
+		return Data{}, err
+	}
+	return Data{Manufacturers: manufacturers, Categories: categories, Cars: cars}, nil
+}
+
+// Refreshes the cache every TTL until the context is cancelled. It does not block.
+// Failed refreshes are logged and the previous data is kept.
+func (c *Cache) Start(ctx context.Context) {
+	if c.ttl <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(c.ttl)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.Refresh(); err != nil {
+					log.Printf("Error refreshing the catalog: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Returns the time of the last successful refresh.
+func (c *Cache) RefreshedAt() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.refreshedAt
+}
+
+// Reports whether the last refresh failed, so the data served may be out of date.
+func (c *Cache) Stale() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lastErr != nil
+}
+
+// Returns the error of the last refresh, or nil if it succeeded.
+func (c *Cache) LastError() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lastErr
+}
+
+// Returns a copy of all the cars.
+func (c *Cache) Cars() []models.Car {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]models.Car(nil), c.cars...)
+}
+
+// Returns the car with the ID given, or an error wrapping client.ErrNotFound if there is no such car.
+func (c *Cache) Car(id int) (models.Car, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, car := range c.cars {
+		if car.Id == id {
+			return car, nil
+		}
+	}
+	return models.Car{}, fmt.Errorf("car %d: %w", id, client.ErrNotFound)
+}
+
+// Returns the cars with any of the words of the query in their name, manufacturer, category, country
+// or specifications, the most relevant first.
+func (c *Cache) Search(query string) []models.Car {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.index == nil {
+		return nil
+	}
+	byID := make(map[int]models.Car, len(c.cars))
+	for _, car := range c.cars {
+		byID[car.Id] = car
+	}
+	var cars []models.Car
+	for _, result := range c.index.Search(query) {
+		cars = append(cars, byID[result.CarID])
+	}
+	return cars
+}
+
+// Returns the query with the words that are in no car replaced by the closest ones that are,
+// to suggest it when a search finds nothing. Returns "" when there is nothing to suggest.
+func (c *Cache) Suggest(query string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.index == nil {
+		return ""
+	}
+	return c.index.Suggest(query)
+}
+
+// Returns a copy of all the manufacturers.
+func (c *Cache) Manufacturers() []models.Manufacturers {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]models.Manufacturers(nil), c.manufacturers...)
+}
+
+// Returns the manufacturer with the ID given, or an error wrapping client.ErrNotFound if there is no such manufacturer.
+func (c *Cache) Manufacturer(id int) (models.Manufacturers, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, manufacturer := range c.manufacturers {
+		if manufacturer.Id == id {
+			return manufacturer, nil
+		}
+	}
+	return models.Manufacturers{}, fmt.Errorf("manufacturer %d: %w", id, client.ErrNotFound)
+}
+
+// Returns a copy of all the categories.
+func (c *Cache) Categories() []models.Categories {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]models.Categories(nil), c.categories...)
+}
+
+// Returns the category with the ID given, or an error wrapping client.ErrNotFound if there is no such category.
+func (c *Cache) Category(id int) (models.Categories, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, category := range c.categories {
+		if category.Id == id {
+			return category, nil
+		}
+	}
+	return models.Categories{}, fmt.Errorf("category %d: %w", id, client.ErrNotFound)
+}
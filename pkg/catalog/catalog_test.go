@@ -0,0 +1,270 @@
+package catalog
+
+import (
+	"cars/pkg/client"
+	"cars/pkg/models"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testData(names ...string) Data {
+	data := Data{
+		Manufacturers: []models.Manufacturers{{Id: 1, Name: "Toyota", Country: "Japan"}},
+		Categories:    []models.Categories{{Id: 1, Name: "Sedan"}},
+	}
+	for i, name := range names {
+		data.Cars = append(data.Cars, models.Car{Id: i + 1, Name: name, ManufacturerID: 1, CategoryID: 1, Year: 2023})
+	}
+	return data
+}
+
+func carNames(cars []models.Car) []string {
+	var names []string
+	for _, car := range cars {
+		names = append(names, car.Name)
+	}
+	return names
+}
+
+// switchSource serves a MemorySource that can be replaced, or fails while failing is set.
+// It is not a DataSource, so the cache reads the cars, manufacturers and categories at the same time.
+type switchSource struct {
+	mu      sync.Mutex
+	memory  *MemorySource
+	failing bool
+	// failCategories makes only the categories fail.
+	failCategories bool
+}
+
+func newSwitchSource(data Data) *switchSource {
+	return &switchSource{memory: NewMemorySource(data, "/images/")}
+}
+
+func (s *switchSource) set(data Data, failing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.memory = NewMemorySource(data, "/images/")
+	s.failing = failing
+}
+
+func (s *switchSource) source(categories bool) (*MemorySource, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failing || (categories && s.failCategories) {
+		return nil, client.ErrUnavailable
+	}
+	return s.memory, nil
+}
+
+func (s *switchSource) Cars() ([]models.Car, error) {
+	memory, err := s.source(false)
+	if err != nil {
+		return nil, err
+	}
+	return memory.Cars()
+}
+
+func (s *switchSource) Manufacturers() ([]models.Manufacturers, error) {
+	memory, err := s.source(false)
+	if err != nil {
+		return nil, err
+	}
+	return memory.Manufacturers()
+}
+
+func (s *switchSource) Categories() ([]models.Categories, error) {
+	memory, err := s.source(true)
+	if err != nil {
+		return nil, err
+	}
+	return memory.Categories()
+}
+
+func (s *switchSource) ImageURL(image string) string {
+	return "/images/" + image
+}
+
+func TestRefresh(t *testing.T) {
+	cache := New(NewMemorySource(testData("Corolla", "Camry"), "/images/"), time.Minute)
+	if len(cache.Cars()) != 0 || !cache.RefreshedAt().IsZero() {
+		t.Fatal("the cache has data before the first refresh")
+	}
+
+	if err := cache.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if got := carNames(cache.Cars()); !slices.Equal(got, []string{"Corolla", "Camry"}) {
+		t.Errorf("Cars() = %v, want [Corolla Camry]", got)
+	}
+	if car, err := cache.Car(2); err != nil || car.Name != "Camry" {
+		t.Errorf("Car(2) = %v, %v, want Camry", car, err)
+	}
+	if _, err := cache.Car(9); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("Car(9) error = %v, want ErrNotFound", err)
+	}
+	if _, err := cache.Manufacturer(1); err != nil {
+		t.Errorf("Manufacturer(1) error = %v", err)
+	}
+	if got := carNames(cache.Search("camry")); !slices.Equal(got, []string{"Camry"}) {
+		t.Errorf("Search(camry) = %v, want [Camry]", got)
+	}
+	if cache.Stale() || cache.LastError() != nil || cache.RefreshedAt().IsZero() {
+		t.Errorf("after a refresh: stale %v, error %v, refreshed at %v", cache.Stale(), cache.LastError(), cache.RefreshedAt())
+	}
+	if got := cache.ImageURL("corolla.jpg"); got != "/images/corolla.jpg" {
+		t.Errorf("ImageURL = %q", got)
+	}
+}
+
+func TestRefreshDoesNotShareData(t *testing.T) {
+	cache := New(NewMemorySource(testData("Corolla"), ""), time.Minute)
+	if err := cache.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	cache.Cars()[0].Name = "Changed"
+	if got := cache.Cars()[0].Name; got != "Corolla" {
+		t.Errorf("changing the cars returned changed the cache: %q", got)
+	}
+}
+
+func TestServeStaleDataWhenRefreshFails(t *testing.T) {
+	source := newSwitchSource(testData("Corolla"))
+	cache := New(source, time.Minute)
+	if err := cache.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	refreshedAt := cache.RefreshedAt()
+
+	source.set(testData("Camry"), true)
+	if err := cache.Refresh(); !errors.Is(err, client.ErrUnavailable) {
+		t.Fatalf("Refresh() = %v, want ErrUnavailable", err)
+	}
+	if !cache.Stale() || !errors.Is(cache.LastError(), client.ErrUnavailable) {
+		t.Errorf("stale %v, error %v, want stale with ErrUnavailable", cache.Stale(), cache.LastError())
+	}
+	if got := carNames(cache.Cars()); !slices.Equal(got, []string{"Corolla"}) {
+		t.Errorf("Cars() = %v, want the last good copy [Corolla]", got)
+	}
+	if got := carNames(cache.Search("corolla")); !slices.Equal(got, []string{"Corolla"}) {
+		t.Errorf("Search(corolla) = %v, want the last good index", got)
+	}
+	if !cache.RefreshedAt().Equal(refreshedAt) {
+		t.Errorf("a failed refresh changed the time of the last refresh")
+	}
+
+	source.set(testData("Camry"), false)
+	if err := cache.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if cache.Stale() {
+		t.Error("the cache is stale after a successful refresh")
+	}
+	if got := carNames(cache.Cars()); !slices.Equal(got, []string{"Camry"}) {
+		t.Errorf("Cars() = %v, want [Camry]", got)
+	}
+}
+
+func TestPartialFailureKeepsEverything(t *testing.T) {
+	source := newSwitchSource(testData("Corolla"))
+	cache := New(source, time.Minute)
+	if err := cache.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+
+	//	The cars are read fine, but they must not be replaced while the categories fail.
+	source.set(testData("Camry"), false)
+	source.mu.Lock()
+	source.failCategories = true
+	source.mu.Unlock()
+	if err := cache.Refresh(); err == nil {
+		t.Fatal("Refresh() = nil, want an error")
+	}
+	if got := carNames(cache.Cars()); !slices.Equal(got, []string{"Corolla"}) {
+		t.Errorf("Cars() = %v, want [Corolla]", got)
+	}
+}
+
+func TestFirstRefreshFails(t *testing.T) {
+	source := newSwitchSource(Data{})
+	source.set(Data{}, true)
+	cache := New(source, time.Minute)
+	if err := cache.Refresh(); err == nil {
+		t.Fatal("Refresh() = nil, want an error")
+	}
+	if !cache.Stale() || len(cache.Cars()) != 0 || cache.Search("corolla") != nil || cache.Suggest("corola") != "" {
+		t.Error("a cache that was never filled must be stale and empty")
+	}
+}
+
+// countingSource is a DataSource that counts how many times the whole catalog is read.
+type countingSource struct {
+	*switchSource
+	mu    sync.Mutex
+	reads int
+}
+
+func (s *countingSource) Data() (Data, error) {
+	s.mu.Lock()
+	s.reads++
+	s.mu.Unlock()
+	memory, err := s.source(false)
+	if err != nil {
+		return Data{}, err
+	}
+	return memory.Data, nil
+}
+
+func (s *countingSource) Cars() ([]models.Car, error) {
+	panic("a DataSource must be read with Data")
+}
+
+func TestRefreshReadsDataSourceOnce(t *testing.T) {
+	source := &countingSource{switchSource: newSwitchSource(testData("Corolla"))}
+	cache := New(source, time.Minute)
+	if err := cache.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if source.reads != 1 {
+		t.Errorf("the source was read %d times, want 1", source.reads)
+	}
+	if got := carNames(cache.Cars()); !slices.Equal(got, []string{"Corolla"}) {
+		t.Errorf("Cars() = %v, want [Corolla]", got)
+	}
+}
+
+func TestStartRefreshesEveryTTL(t *testing.T) {
+	source := newSwitchSource(testData("Corolla"))
+	cache := New(source, 10*time.Millisecond)
+	if err := cache.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cache.Start(ctx)
+
+	source.set(testData("Camry"), false)
+	deadline := time.Now().Add(2 * time.Second)
+	for !slices.Equal(carNames(cache.Cars()), []string{"Camry"}) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Cars() = %v after 2s, want the new data", carNames(cache.Cars()))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStartWithoutTTL(t *testing.T) {
+	source := newSwitchSource(testData("Corolla"))
+	cache := New(source, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cache.Start(ctx)
+
+	time.Sleep(50 * time.Millisecond)
+	if len(cache.Cars()) != 0 {
+		t.Error("the cache was refreshed in the background without a TTL")
+	}
+}
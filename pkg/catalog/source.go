@@ -0,0 +1,115 @@
+package catalog
+
+import (
+	"cars/pkg/client"
+	"cars/pkg/models"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Source is where the catalog data comes from: the HTTP API, a JSON file or memory.
+type Source interface {
+	Cars() ([]models.Car, error)
+	Manufacturers() ([]models.Manufacturers, error)
+	Categories() ([]models.Categories, error)
+	// Returns the URL the browser uses to load the image of a car.
+	ImageURL(image string) string
+}
+
+// DataSource is a Source that can read the whole catalog at once. Cache.Refresh prefers it,
+// so the cars, manufacturers and categories of a refresh come from the same version of the data.
+type DataSource interface {
+	Source
+	Data() (Data, error)
+}
+
+// The API client is the default Source.
+var _ Source = (*client.Client)(nil)
+
+var _ DataSource = (*FileSource)(nil)
+
+// Data has the same shape as api/data.json.
+type Data struct {
+	Manufacturers []models.Manufacturers `json:"manufacturers"`
+	Categories    []models.Categories    `json:"categories"`
+	Cars          []models.Car           `json:"carModels"`
+}
+
+// Reads a JSON file with the same shape as api/data.json.
+// Like the API client, errors wrap client.ErrUnavailable or client.ErrDecode.
+func ReadDataFile(path string) (Data, error) {
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return Data{}, fmt.Errorf("%w: %w", client.ErrUnavailable, err)
+	}
+	var data Data
+	if err := json.Unmarshal(file, &data); err != nil {
+		return Data{}, fmt.Errorf("%s: %w: %w", path, client.ErrDecode, err)
+	}
+	return data, nil
+}
+
+// FileSource reads the catalog from a local JSON file, so the server can run without the API.
+// The file is read again on every refresh, so changes are picked up without a restart.
+type FileSource struct {
+	Path string
+	// ImagesURL is the URL prefix where the images are served, e.g. "/images/".
+	ImagesURL string
+}
+
+// Creates a FileSource for the JSON file at path.
+func NewFileSource(path, imagesURL string) *FileSource {
+	return &FileSource{Path: path, ImagesURL: imagesURL}
+}
+
+// Reads the file once for the cars, manufacturers and categories.
+func (s *FileSource) Data() (Data, error) {
+	return ReadDataFile(s.Path)
+}
+
+func (s *FileSource) Cars() ([]models.Car, error) {
+	data, err := ReadDataFile(s.Path)
+	return data.Cars, err
+}
+
+func (s *FileSource) Manufacturers() ([]models.Manufacturers, error) {
+	data, err := ReadDataFile(s.Path)
+	return data.Manufacturers, err
+}
+
+func (s *FileSource) Categories() ([]models.Categories, error) {
+	data, err := ReadDataFile(s.Path)
+	return data.Categories, err
+}
+
+func (s *FileSource) ImageURL(image string) string {
+	return s.ImagesURL + image
+}
+
+// MemorySource serves a fixed catalog from memory. It is useful to inject fixture data.
+type MemorySource struct {
+	Data      Data
+	ImagesURL string
+}
+
+// Creates a MemorySource serving the data given.
+func NewMemorySource(data Data, imagesURL string) *MemorySource {
+	return &MemorySource{Data: data, ImagesURL: imagesURL}
+}
+
+func (s *MemorySource) Cars() ([]models.Car, error) {
+	return append([]models.Car(nil), s.Data.Cars...), nil
+}
+
+func (s *MemorySource) Manufacturers() ([]models.Manufacturers, error) {
+	return append([]models.Manufacturers(nil), s.Data.Manufacturers...), nil
+}
+
+func (s *MemorySource) Categories() ([]models.Categories, error) {
+	return append([]models.Categories(nil), s.Data.Categories...), nil
+}
+
+func (s *MemorySource) ImageURL(image string) string {
+	return s.ImagesURL + image
+}
@@ -0,0 +1,129 @@
+package client
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the API while the circuit breaker is open.
+// It is an ErrUnavailable.
+var ErrCircuitOpen = fmt.Errorf("%w: circuit breaker is open", ErrUnavailable)
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState int
+
+const (
+	// Requests go through normally.
+	BreakerClosed BreakerState = iota
+	// Requests fail fast without calling the API.
+	BreakerOpen
+	// One trial request is let through to check if the API is back.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// Breaker is a circuit breaker. It opens after Threshold consecutive failures,
+// rejects every request during Cooldown, and then lets one trial request through:
+// the breaker closes again if it succeeds, or stays open for another Cooldown if it fails.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// Creates a closed Breaker.
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// Returns the current state of the breaker.
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Returns ErrCircuitOpen if the request must not be sent, and nil otherwise.
+// Every allowed request must be followed by a call to Success, Failure or Release.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.setState(BreakerHalfOpen)
+		b.trial = true
+		return nil
+	case BreakerHalfOpen:
+		//	Only one trial request at a time.
+		if b.trial {
+			return ErrCircuitOpen
+		}
+		b.trial = true
+	}
+	return nil
+}
+
+// Records a successful request.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.trial = false
+	if b.state != BreakerClosed {
+		b.setState(BreakerClosed)
+	}
+}
+
+// Records a failed request.
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.trial = false
+	if b.state == BreakerHalfOpen || (b.state == BreakerClosed && b.failures >= b.threshold) {
+		b.openedAt = time.Now()
+		b.setState(BreakerOpen)
+	}
+}
+
+// Records a request that was allowed but never reached the API, so it says nothing about it.
+// The failures and the state are kept, and a half-open breaker can let another trial request through.
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+}
+
+// Changes the state and logs the transition. The lock must be held.
+func (b *Breaker) setState(state BreakerState) {
+	log.Printf("Catalog API circuit breaker: %s -> %s (consecutive failures: %d)", b.state, state, b.failures)
+	b.state = state
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// Ends the cooldown of an open breaker without waiting for it.
+func expireCooldown(b *Breaker) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.openedAt = time.Now().Add(-b.cooldown)
+}
+
+func allow(t *testing.T, b *Breaker) {
+	t.Helper()
+	if err := b.Allow(); err != nil {
+		t.Fatalf("Allow() = %v, want nil in state %s", err, b.State())
+	}
+}
+
+func reject(t *testing.T, b *Breaker) {
+	t.Helper()
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Allow() = %v, want ErrCircuitOpen in state %s", err, b.State())
+	}
+}
+
+func wantState(t *testing.T, b *Breaker, want BreakerState) {
+	t.Helper()
+	if got := b.State(); got != want {
+		t.Fatalf("state is %s, want %s", got, want)
+	}
+}
+
+func TestBreakerOpensAndClosesAgain(t *testing.T) {
+	b := NewBreaker(3, time.Minute)
+
+	//	Failures under the threshold, or broken by a success, keep it closed.
+	for range 2 {
+		allow(t, b)
+		b.Failure()
+	}
+	allow(t, b)
+	b.Success()
+	for range 2 {
+		allow(t, b)
+		b.Failure()
+	}
+	wantState(t, b, BreakerClosed)
+
+	allow(t, b)
+	b.Failure()
+	wantState(t, b, BreakerOpen)
+	reject(t, b)
+
+	expireCooldown(b)
+	allow(t, b)
+	wantState(t, b, BreakerHalfOpen)
+	b.Success()
+	wantState(t, b, BreakerClosed)
+	allow(t, b)
+}
+
+func TestBreakerFailedTrialOpensAgain(t *testing.T) {
+	b := NewBreaker(1, time.Minute)
+	allow(t, b)
+	b.Failure()
+	wantState(t, b, BreakerOpen)
+
+	expireCooldown(b)
+	allow(t, b)
+	b.Failure()
+	wantState(t, b, BreakerOpen)
+	//	A new cooldown starts.
+	reject(t, b)
+}
+
+func TestBreakerSingleTrial(t *testing.T) {
+	b := NewBreaker(1, time.Minute)
+	allow(t, b)
+	b.Failure()
+	expireCooldown(b)
+
+	allow(t, b)
+	//	Only one trial request at a time while half-open.
+	reject(t, b)
+	reject(t, b)
+	b.Success()
+	allow(t, b)
+	allow(t, b)
+}
+
+func TestBreakerRelease(t *testing.T) {
+	b := NewBreaker(2, time.Minute)
+	allow(t, b)
+	b.Failure()
+	//	A request that never reached the API does not reset the failures.
+	allow(t, b)
+	b.Release()
+	allow(t, b)
+	b.Failure()
+	wantState(t, b, BreakerOpen)
+
+	//	A released trial stays half-open and lets the next trial through.
+	expireCooldown(b)
+	allow(t, b)
+	b.Release()
+	wantState(t, b, BreakerHalfOpen)
+	allow(t, b)
+	reject(t, b)
+	b.Success()
+	wantState(t, b, BreakerClosed)
+}
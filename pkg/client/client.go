@@ -0,0 +1,235 @@
+package client
+
+import (
+	"cars/pkg/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the address of the catalog API when it runs locally with `make run`.
+const DefaultBaseURL = "http://localhost:3000"
+
+// DefaultTimeout is the time allowed for a whole request to the catalog API.
+const DefaultTimeout = 10 * time.Second
+
+// DefaultUserAgent is sent with every request unless another one is configured.
+const DefaultUserAgent = "cars-viewer/1.0"
+
+// Client talks to the catalog API (cars, manufacturers, categories and images).
+type Client struct {
+	BaseURL    string
+	UserAgent  string
+	HTTPClient *http.Client
+	// Retry is applied to idempotent requests that fail because of the network or a server error.
+	Retry RetryPolicy
+	// Breaker, when not nil, makes requests fail fast while the API is down.
+	Breaker *Breaker
+}
+
+// Option modifies a Client when it is created with New.
+type Option func(*Client)
+
+// WithTimeout sets the timeout of the underlying http.Client.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.HTTPClient.Timeout = timeout
+	}
+}
+
+// WithHTTPClient replaces the underlying http.Client.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.HTTPClient = httpClient
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.UserAgent = userAgent
+	}
+}
+
+// WithRetry sets the retry policy for idempotent requests.
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.Retry = policy
+	}
+}
+
+// WithBreaker sets the circuit breaker shared by every request of the client.
+func WithBreaker(breaker *Breaker) Option {
+	return func(c *Client) {
+		c.Breaker = breaker
+	}
+}
+
+// Creates a Client for the API found at baseURL, e.g. "http://localhost:3000".
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		UserAgent:  DefaultUserAgent,
+		HTTPClient: &http.Client{Timeout: DefaultTimeout},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Returns the absolute URL for a path of the API, e.g. "/api/models".
+func (c *Client) URL(path string) string {
+	return c.BaseURL + path
+}
+
+// Returns the absolute URL of a car image as served by the API.
+func (c *Client) ImageURL(image string) string {
+	return c.URL("/api/images/" + image)
+}
+
+// Sends a GET request to the path given and decodes the JSON body into v.
+// The errors returned wrap ErrNotFound, ErrUnavailable or ErrDecode.
+func (c *Client) Get(path string, v any) error {
+	resp, err := c.do(http.MethodGet, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		//	The API explains what was not found, e.g. {"message":"Car model not found"}.
+		var body struct {
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&body) == nil && body.Message != "" {
+			return fmt.Errorf("GET %s: %w: %s", path, ErrNotFound, body.Message)
+		}
+		return fmt.Errorf("GET %s: %w", path, ErrNotFound)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return fmt.Errorf("GET %s: %w: unexpected status %s", path, ErrDecode, resp.Status)
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("GET %s: %w: %w", path, ErrDecode, err)
+	}
+	return nil
+}
+
+// Sends a request to the path given, going through the circuit breaker.
+// Idempotent requests are retried following the retry policy.
+func (c *Client) do(method, path string) (*http.Response, error) {
+	attempts := 1
+	if idempotent(method) {
+		attempts += max(c.Retry.MaxRetries, 0)
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(c.Retry.Backoff(attempt))
+		}
+
+		var resp *http.Response
+		resp, err = c.send(method, path)
+		if err == nil {
+			return resp, nil
+		}
+		if errors.Is(err, ErrCircuitOpen) {
+			return nil, err
+		}
+		log.Printf("Request %d/%d to %s failed: %v", attempt+1, attempts, path, err)
+	}
+	return nil, err
+}
+
+// Sends one request. Network errors and retryable statuses are returned as errors
+// and reported to the circuit breaker.
+func (c *Client) send(method, path string) (*http.Response, error) {
+	if c.Breaker != nil {
+		if err := c.Breaker.Allow(); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequest(method, c.URL(path), nil)
+	if err != nil {
+		//	The request could not even be built, so the API is not to blame.
+		if c.Breaker != nil {
+			c.Breaker.Release()
+		}
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		err = fmt.Errorf("%w: %w", ErrUnavailable, err)
+	} else if retryableStatus(resp.StatusCode) {
+		resp.Body.Close()
+		err = fmt.Errorf("%s %s: %w: %s", method, path, ErrUnavailable, resp.Status)
+	}
+
+	if c.Breaker != nil {
+		if err != nil {
+			c.Breaker.Failure()
+		} else {
+			c.Breaker.Success()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Fetch a car from the API by ID.
+func (c *Client) Car(id int) (models.Car, error) {
+	var car models.Car
+	err := c.Get("/api/models/"+strconv.Itoa(id), &car)
+	return car, err
+}
+
+// Fetch all cars from the API.
+func (c *Client) Cars() ([]models.Car, error) {
+	var cars []models.Car
+	err := c.Get("/api/models", &cars)
+	return cars, err
+}
+
+// Fetch a category from the API by ID.
+func (c *Client) Category(id int) (models.Categories, error) {
+	var category models.Categories
+	err := c.Get("/api/categories/"+strconv.Itoa(id), &category)
+	return category, err
+}
+
+// Fetch all categories from the API.
+func (c *Client) Categories() ([]models.Categories, error) {
+	var categories []models.Categories
+	err := c.Get("/api/categories", &categories)
+	return categories, err
+}
+
+// Fetch a manufacturer from the API by ID.
+func (c *Client) Manufacturer(id int) (models.Manufacturers, error) {
+	var manufacturer models.Manufacturers
+	err := c.Get("/api/manufacturers/"+strconv.Itoa(id), &manufacturer)
+	return manufacturer, err
+}
+
+// Fetch all manufacturers from the API.
+func (c *Client) Manufacturers() ([]models.Manufacturers, error) {
+	var manufacturers []models.Manufacturers
+	err := c.Get("/api/manufacturers", &manufacturers)
+	return manufacturers, err
+}
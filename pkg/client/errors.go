@@ -0,0 +1,14 @@
+package client
+
+import "errors"
+
+// Errors returned by the client. They are wrapped with the details of the request,
+// so they must be checked with errors.Is.
+var (
+	// ErrNotFound means the API answered 404: the item requested does not exist.
+	ErrNotFound = errors.New("not found in the catalog")
+	// ErrUnavailable means the API could not be reached or answered with a server error.
+	ErrUnavailable = errors.New("catalog API unavailable")
+	// ErrDecode means the API answered something that could not be understood.
+	ErrDecode = errors.New("invalid response from the catalog API")
+)
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy decides how many times an idempotent request to the API is repeated when it fails,
+// and how long to wait between attempts. The zero value does not retry.
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// maxBackoff is the longest wait between two attempts, also when MaxDelay is not set.
+const maxBackoff = time.Minute
+
+// Returns the time to wait before the retry number attempt (1 for the first retry).
+// The delay grows exponentially from BaseDelay up to MaxDelay, or maxBackoff when MaxDelay is not set
+// or is longer, and a random jitter is applied so that many clients do not retry at the same time.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+	limit := p.MaxDelay
+	if limit <= 0 || limit > maxBackoff {
+		limit = maxBackoff
+	}
+	//	The delay stops doubling once it reaches the limit, so it never overflows.
+	delay := min(p.BaseDelay, limit)
+	for i := 1; i < attempt && delay < limit; i++ {
+		delay = min(delay*2, limit)
+	}
+	//	Wait a random time between half the delay and the whole delay.
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+// Reports whether a request with the method given can be sent again safely.
+func idempotent(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// Reports whether a response status is worth retrying: server errors and rate limiting.
+func retryableStatus(status int) bool {
+	return status >= http.StatusInternalServerError || status == http.StatusTooManyRequests
+}
@@ -0,0 +1,35 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		// The delay is between half of max and max, because of the jitter.
+		max time.Duration
+	}{
+		{"no base delay", RetryPolicy{MaxDelay: time.Second}, 3, 0},
+		{"first retry", RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}, 1, 100 * time.Millisecond},
+		{"doubles", RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}, 3, 400 * time.Millisecond},
+		{"capped by MaxDelay", RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}, 10, time.Second},
+		{"base over MaxDelay", RetryPolicy{BaseDelay: 5 * time.Second, MaxDelay: time.Second}, 1, time.Second},
+		{"no MaxDelay", RetryPolicy{BaseDelay: 100 * time.Millisecond}, 1000, maxBackoff},
+		{"MaxDelay over maxBackoff", RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Hour}, 1000, maxBackoff},
+		{"largest attempt", RetryPolicy{BaseDelay: time.Nanosecond}, int(^uint(0) >> 1), maxBackoff},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for range 20 {
+				got := test.policy.Backoff(test.attempt)
+				if got < test.max/2 || got > test.max {
+					t.Fatalf("Backoff(%d) = %v, want from %v to %v", test.attempt, got, test.max/2, test.max)
+				}
+			}
+		})
+	}
+}
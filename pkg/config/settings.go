@@ -0,0 +1,151 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Settings holds the values the server is started with.
+// Every setting can be given as a flag, or as an environment variable when the flag is missing.
+type Settings struct {
+	Addr string
+	// Source is where the catalog is read from: "api", "file" or "embedded".
+	Source    string
+	DataFile  string
+	ImagesDir string
+
+	APIURL     string
+	APITimeout time.Duration
+	UserAgent  string
+	CacheTTL   time.Duration
+	SessionTTL time.Duration
+	// StateFile is where sessions are persisted. Empty keeps them only in memory.
+	StateFile string
+	// UsersFile is where the user accounts are persisted. Empty keeps them only in memory.
+	UsersFile string
+	// SharesFile is where the saved comparisons are persisted. Empty keeps them only in memory.
+	SharesFile string
+	// ReviewsFile is where the reviews of the cars are persisted. Empty keeps them only in memory.
+	ReviewsFile string
+	// Moderators are the usernames of the accounts that approve or reject the reviews.
+	Moderators []string
+
+	APIRetries       int
+	APIRetryDelay    time.Duration
+	APIRetryMaxDelay time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// Reads the settings from the command line arguments (without the program name).
+// Environment variables are used as the defaults of the flags.
+func ParseSettings(args []string) (Settings, error) {
+	var settings Settings
+
+	apiTimeout, err := envDuration("CARS_API_TIMEOUT", 10*time.Second)
+	if err != nil {
+		return Settings{}, err
+	}
+
+	cacheTTL, err := envDuration("CARS_CACHE_TTL", 5*time.Minute)
+	if err != nil {
+		return Settings{}, err
+	}
+
+	sessionTTL, err := envDuration("CARS_SESSION_TTL", 30*24*time.Hour)
+	if err != nil {
+		return Settings{}, err
+	}
+	apiRetries, err := envInt("CARS_API_RETRIES", 2)
+	if err != nil {
+		return Settings{}, err
+	}
+	apiRetryDelay, err := envDuration("CARS_API_RETRY_DELAY", 200*time.Millisecond)
+	if err != nil {
+		return Settings{}, err
+	}
+	apiRetryMaxDelay, err := envDuration("CARS_API_RETRY_MAX_DELAY", 2*time.Second)
+	if err != nil {
+		return Settings{}, err
+	}
+	breakerThreshold, err := envInt("CARS_BREAKER_THRESHOLD", 5)
+	if err != nil {
+		return Settings{}, err
+	}
+	breakerCooldown, err := envDuration("CARS_BREAKER_COOLDOWN", 30*time.Second)
+	if err != nil {
+		return Settings{}, err
+	}
+
+	flags := flag.NewFlagSet("cars", flag.ContinueOnError)
+	flags.StringVar(&settings.Addr, "addr", envString("CARS_ADDR", ":8080"), "address where the web server listens (env CARS_ADDR)")
+	flags.StringVar(&settings.Source, "source", envString("CARS_SOURCE", "api"), `where the catalog is read from: "api", "file" or "embedded" to serve the API from this process (env CARS_SOURCE)`)
+	flags.StringVar(&settings.DataFile, "data-file", envString("CARS_DATA_FILE", "api/data.json"), "JSON file read when the source is \"file\" or \"embedded\" (env CARS_DATA_FILE)")
+	flags.StringVar(&settings.ImagesDir, "images-dir", envString("CARS_IMAGES_DIR", "api/img"), "directory with the car images, served when the source is \"file\" or \"embedded\" (env CARS_IMAGES_DIR)")
+	flags.StringVar(&settings.APIURL, "api-url", envString("CARS_API_URL", "http://localhost:3000"), "base URL of the catalog API (env CARS_API_URL)")
+	flags.DurationVar(&settings.APITimeout, "api-timeout", apiTimeout, "timeout for each request to the catalog API (env CARS_API_TIMEOUT)")
+	flags.StringVar(&settings.UserAgent, "user-agent", envString("CARS_USER_AGENT", "cars-viewer/1.0"), "User-Agent sent to the catalog API (env CARS_USER_AGENT)")
+	flags.DurationVar(&settings.CacheTTL, "cache-ttl", cacheTTL, "how often the catalog cache is refreshed from the API (env CARS_CACHE_TTL)")
+	flags.DurationVar(&settings.SessionTTL, "session-ttl", sessionTTL, "how long a visitor session lives without visits (env CARS_SESSION_TTL)")
+	flags.StringVar(&settings.StateFile, "state-file", envString("CARS_STATE_FILE", "data/state.json"), "file where favourites, comparisons and preferences are saved, empty to keep them only in memory (env CARS_STATE_FILE)")
+	flags.StringVar(&settings.UsersFile, "users-file", envString("CARS_USERS_FILE", "data/users.json"), "file where the user accounts are saved, empty to keep them only in memory (env CARS_USERS_FILE)")
+	flags.StringVar(&settings.SharesFile, "shares-file", envString("CARS_SHARES_FILE", "data/shares.json"), "file where the comparisons saved to be shared are kept, empty to keep them only in memory (env CARS_SHARES_FILE)")
+	flags.StringVar(&settings.ReviewsFile, "reviews-file", envString("CARS_REVIEWS_FILE", "data/reviews.json"), "file where the reviews of the cars are saved, empty to keep them only in memory (env CARS_REVIEWS_FILE)")
+	moderators := flags.String("moderators", envString("CARS_MODERATORS", ""), "comma separated usernames of the accounts that moderate the reviews (env CARS_MODERATORS)")
+	flags.IntVar(&settings.APIRetries, "api-retries", apiRetries, "times a failed request to the catalog API is retried (env CARS_API_RETRIES)")
+	flags.DurationVar(&settings.APIRetryDelay, "api-retry-delay", apiRetryDelay, "delay before the first retry, doubled on every retry (env CARS_API_RETRY_DELAY)")
+	flags.DurationVar(&settings.APIRetryMaxDelay, "api-retry-max-delay", apiRetryMaxDelay, "maximum delay between retries (env CARS_API_RETRY_MAX_DELAY)")
+	flags.IntVar(&settings.BreakerThreshold, "breaker-threshold", breakerThreshold, "consecutive failures that open the circuit breaker, 0 disables it (env CARS_BREAKER_THRESHOLD)")
+	flags.DurationVar(&settings.BreakerCooldown, "breaker-cooldown", breakerCooldown, "time the circuit breaker stays open before trying the API again (env CARS_BREAKER_COOLDOWN)")
+
+	if err := flags.Parse(args); err != nil {
+		return Settings{}, err
+	}
+	if settings.Source != "api" && settings.Source != "file" && settings.Source != "embedded" {
+		return Settings{}, fmt.Errorf("invalid source %q: must be \"api\", \"file\" or \"embedded\"", settings.Source)
+	}
+	for _, username := range strings.Split(*moderators, ",") {
+		if username = strings.TrimSpace(username); username != "" {
+			settings.Moderators = append(settings.Moderators, username)
+		}
+	}
+	return settings, nil
+}
+
+// Returns the value of the environment variable key, or def when it is not set.
+func envString(key, def string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return def
+}
+
+// Returns the value of the environment variable key parsed as an int, or def when it is not set.
+func envInt(key string, def int) (int, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return def, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return number, nil
+}
+
+// Returns the value of the environment variable key parsed as a time.Duration, or def when it is not set.
+func envDuration(key string, def time.Duration) (time.Duration, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return def, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return duration, nil
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"cars/pkg/accounts"
+	"cars/pkg/helpers"
+	"cars/pkg/session"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var loginTemplates = []string{
+	"web/templates/login.html",
+	"web/templates/main-bar.html",
+}
+
+var accountTemplates = []string{
+	"web/templates/account.html",
+	"web/templates/main-bar.html",
+}
+
+// errPasswordMismatch is shown when the password and its confirmation are different.
+var errPasswordMismatch = errors.New("the passwords do not match")
+
+// Responds with the login and registration forms, and logs the user in when the login form is sent.
+func LoginPage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/login" {
+		fmt.Println("Error. Path Not Allowed. Login")
+		http.Error(w, "404 Not Found", http.StatusNotFound)
+		return
+	}
+
+	sess := session.FromRequest(r)
+
+	switch r.Method {
+	case http.MethodGet:
+		if sess.Username != "" {
+			http.Redirect(w, r, "/account", http.StatusSeeOther)
+			return
+		}
+		helpers.RenderTemplate(w, loginTemplates, "login.html", NewDataResponse(sess))
+
+	case http.MethodPost:
+		if err := r.ParseForm(); err != nil {
+			fmt.Println("Error Parsing Form")
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+
+		user, err := helpers.Accounts.Authenticate(r.Form.Get("username"), r.Form.Get("password"))
+		if errors.Is(err, accounts.ErrInvalidCredentials) {
+			data := NewDataResponse(sess)
+			data.Message = err.Error()
+			w.WriteHeader(http.StatusUnauthorized)
+			helpers.RenderTemplate(w, loginTemplates, "login.html", data)
+			return
+		}
+		if err != nil {
+			fmt.Println("Error logging in: ", err)
+			ErrorPage(w, r, http.StatusInternalServerError)
+			return
+		}
+
+		logIn(w, r, sess, user)
+		http.Redirect(w, r, "/account", http.StatusSeeOther)
+
+	default:
+		w.Header().Set("Allow", http.MethodGet+", "+http.MethodPost)
+		http.Error(w, "Method is not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Creates a new account with the registration form, and logs the user in.
+func Register(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/register" {
+		fmt.Println("Error. Path Not Allowed. Register")
+		http.Error(w, "404 Not Found", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method is not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sess := session.FromRequest(r)
+
+	if err := r.ParseForm(); err != nil {
+		fmt.Println("Error Parsing Form")
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	//	Both passwords must match, to avoid typos in a password that is not visible.
+	password := r.Form.Get("password")
+	var user accounts.User
+	var err error
+	if password != r.Form.Get("confirm") {
+		err = errPasswordMismatch
+	} else {
+		user, err = helpers.Accounts.Register(r.Form.Get("username"), password)
+	}
+
+	if err != nil {
+		if errors.Is(err, accounts.ErrInvalidUsername) || errors.Is(err, accounts.ErrWeakPassword) ||
+			errors.Is(err, accounts.ErrLongPassword) || errors.Is(err, accounts.ErrUsernameTaken) ||
+			errors.Is(err, errPasswordMismatch) {
+			data := NewDataResponse(sess)
+			data.Message = err.Error()
+			w.WriteHeader(http.StatusBadRequest)
+			helpers.RenderTemplate(w, loginTemplates, "login.html", data)
+			return
+		}
+		fmt.Println("Error registering user: ", err)
+		ErrorPage(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	logIn(w, r, sess, user)
+	http.Redirect(w, r, "/account", http.StatusSeeOther)
+}
+
+// Logs the user out and starts a new anonymous session.
+func Logout(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/logout" {
+		fmt.Println("Error. Path Not Allowed. Logout")
+		http.Error(w, "404 Not Found", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method is not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session.Logout(w, r)
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// Responds with the "My account" page of the user logged in.
+func AccountPage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/account" {
+		fmt.Println("Error. Path Not Allowed. Account")
+		http.Error(w, "404 Not Found", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method is not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sess := session.FromRequest(r)
+	user, ok := helpers.Accounts.Get(sess.Username)
+	if sess.Username == "" || !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	data := NewDataResponse(sess)
+	data.Account.Username = user.Username
+	data.Account.MemberSince = user.Created.Format("2 January 2006")
+	data.Account.Favourites = len(sess.State.Favourites())
+	data.Account.LastCompare = len(sess.State.LastCompare())
+
+	helpers.RenderTemplate(w, accountTemplates, "account.html", data)
+}
+
+// Starts the session of the user. The favourites and comparisons made before logging in
+// are merged into the account, so nothing is lost.
+func logIn(w http.ResponseWriter, r *http.Request, sess *session.Session, user accounts.User) {
+	account := helpers.Accounts.State(user.Username)
+	if sess.Username == "" {
+		account.Merge(sess.State.Snapshot())
+	}
+	session.Login(w, r, user.Username, account)
+}
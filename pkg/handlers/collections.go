@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"cars/pkg/helpers"
+	"cars/pkg/session"
+	"cars/pkg/state"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+var collectionsTemplates = []string{
+	"web/templates/collections.html",
+	"web/templates/main-bar.html",
+}
+
+var collectionTemplates = []string{
+	"web/templates/collection.html",
+	"web/templates/main-bar.html",
+	"web/templates/card-template.html",
+}
+
+// Responds with the list of collections, and creates, renames, deletes or adds cars to them
+// when one of their forms is sent.
+func CollectionsPage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/collections" {
+		fmt.Println("Error. Path Not Allowed. Collections")
+		http.Error(w, "404 Not Found", http.StatusNotFound)
+		return
+	}
+
+	sess := session.FromRequest(r)
+
+	switch r.Method {
+	case http.MethodGet:
+		renderCollections(w, sess, "")
+
+	case http.MethodPost:
+		if err := r.ParseForm(); err != nil {
+			fmt.Println("Error Parsing Form")
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		changeCollection(w, r, sess)
+
+	default:
+		w.Header().Set("Allow", http.MethodGet+", "+http.MethodPost)
+		http.Error(w, "Method is not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Responds with the page of one collection, with a big card for each of its cars.
+func CollectionPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method is not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sess := session.FromRequest(r)
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		PageNotFound(w, r, "Sorry, we could not find that collection.")
+		return
+	}
+	renderCollection(w, r, sess, id, "")
+}
+
+// Makes the change to the collections asked by the form. The trigger tells which change it is:
+// create -> name
+// rename -> collection_id, name
+// delete -> collection_id
+// add    -> collection_id, form_id (the car is removed if it was already in the collection)
+func changeCollection(w http.ResponseWriter, r *http.Request, sess *session.Session) {
+	trigger := r.Form.Get("trigger")
+
+	if trigger == "create" {
+		_, err := sess.State.CreateCollection(r.Form.Get("name"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			renderCollections(w, sess, err.Error())
+			return
+		}
+		http.Redirect(w, r, "/collections", http.StatusSeeOther)
+		return
+	}
+
+	id, err := strconv.Atoi(r.Form.Get("collection_id"))
+	if err != nil {
+		fmt.Println("Error converting collection_id.")
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	switch trigger {
+	case "rename":
+		err = sess.State.RenameCollection(id, r.Form.Get("name"))
+		if err == nil {
+			http.Redirect(w, r, collectionURL(id), http.StatusSeeOther)
+			return
+		}
+
+	case "delete":
+		err = sess.State.DeleteCollection(id)
+		if err == nil {
+			http.Redirect(w, r, "/collections", http.StatusSeeOther)
+			return
+		}
+
+	case "add":
+		carID, convErr := strconv.Atoi(r.Form.Get("form_id"))
+		if convErr != nil {
+			fmt.Println("Error converting form_id.")
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		//	Only cars of the catalog can be added.
+		if _, err := helpers.Catalog.Car(carID); err != nil {
+			CatalogError(w, r, err)
+			return
+		}
+		_, err = sess.State.ToggleInCollection(id, carID)
+		if err == nil {
+			http.Redirect(w, r, returnURL(r, collectionURL(id)), http.StatusSeeOther)
+			return
+		}
+
+	default:
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case errors.Is(err, state.ErrCollectionNotFound):
+		PageNotFound(w, r, "Sorry, we could not find that collection.")
+	case errors.Is(err, state.ErrInvalidCollectionName), errors.Is(err, state.ErrCollectionExists):
+		w.WriteHeader(http.StatusBadRequest)
+		renderCollection(w, r, sess, id, err.Error())
+	default:
+		fmt.Println("Error changing collection: ", err)
+		ErrorPage(w, r, http.StatusInternalServerError)
+	}
+}
+
+// Renders the list of collections, with the message given.
+func renderCollections(w http.ResponseWriter, sess *session.Session, message string) {
+	data := NewDataResponse(sess)
+	data.Collections = helpers.CreateCollections(sess.State.Collections(), 0)
+	data.Message = message
+	helpers.RenderTemplate(w, collectionsTemplates, "collections.html", data)
+}
+
+// Renders the page of the collection with the ID given, with the message given.
+func renderCollection(w http.ResponseWriter, r *http.Request, sess *session.Session, id int, message string) {
+	collection, ok := sess.State.Collection(id)
+	if !ok {
+		PageNotFound(w, r, "Sorry, we could not find that collection.")
+		return
+	}
+
+	cards, err := helpers.CreateBigCardsBatch(helpers.FetchCarsByID(collection.Cars), sess.State)
+	if err != nil {
+		fmt.Println("Error creating cards.")
+		http.Error(w, "Error creating cards.", http.StatusInternalServerError)
+		return
+	}
+
+	data := NewDataResponse(sess)
+	data.Collection = helpers.CreateCollections([]state.Collection{collection}, 0)[0]
+	data.ExtCard = cards
+	data.NoResults = len(cards) == 0
+	data.Message = message
+	helpers.RenderTemplate(w, collectionTemplates, "collection.html", data)
+}
+
+func collectionURL(id int) string {
+	return "/collections/" + strconv.Itoa(id)
+}
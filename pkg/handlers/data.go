@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"cars/pkg/helpers"
+	"cars/pkg/models"
+	"cars/pkg/session"
+	"net/http"
+)
+
+// Creates the DataResponse with the fields every page needs: the state of the compare button,
+// the banner shown when the catalog may be out of date, the user logged in, whether they
+// moderate the reviews and the tags of the visitor for the filter menu.
+func NewDataResponse(sess *session.Session) models.DataResponse {
+	var data models.DataResponse
+	data.CompareActive = sess.State.CompareActive()
+	data.Stale = helpers.Catalog.Stale()
+	data.Username = sess.Username
+	data.Moderator = helpers.IsModerator(sess.Username)
+	data.Tags = sess.State.AllTags()
+	return data
+}
+
+// Returns the full URL of the path given in this server, to be pasted somewhere else.
+func absoluteURL(r *http.Request, path string) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + path
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"cars/pkg/client"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+)
+
+// Responds with the error page that matches an error returned by the catalog:
+// 404 when the car does not exist, 503 when the API is down, 502 when the API answered
+// something that could not be understood, and 500 for anything else.
+func CatalogError(w http.ResponseWriter, r *http.Request, err error) {
+	fmt.Println("Error reading the catalog: ", err)
+
+	switch {
+	case errors.Is(err, client.ErrNotFound):
+		PageNotFound(w, r, "Sorry, we could not find that car.")
+	case errors.Is(err, client.ErrUnavailable):
+		ErrorPage(w, r, http.StatusServiceUnavailable)
+	case errors.Is(err, client.ErrDecode):
+		ErrorPage(w, r, http.StatusBadGateway)
+	default:
+		ErrorPage(w, r, http.StatusInternalServerError)
+	}
+}
+
+// Responds with the 404 page, showing the message given.
+func PageNotFound(w http.ResponseWriter, r *http.Request, message string) {
+	tmpl, err := template.ParseFiles("web/templates/404.html")
+	if err != nil {
+		fmt.Println("Error Parsing 404HTML Template: ", err)
+		http.Error(w, "404 Not Found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNotFound)
+	err = tmpl.Execute(w, message)
+	if err != nil {
+		fmt.Println("Error Executing 404HTML Template: ", err)
+	}
+}
+
+// Responds with the error page and the status code given.
+func ErrorPage(w http.ResponseWriter, r *http.Request, status int) {
+	w.WriteHeader(status)
+	NotFoundHandler(w, r)
+}
@@ -1,13 +1,17 @@
 package handlers
 
 import (
-	"cars/pkg/config"
 	"cars/pkg/helpers"
 	"cars/pkg/models"
+	"cars/pkg/search"
+	"cars/pkg/session"
+	"cars/pkg/state"
+	"errors"
 	"fmt"
 	"html/template"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 // Responds with the index page including the gallery of all the cars from the API.
@@ -23,29 +27,14 @@ func Homepage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	//	We store the current URL to keep track of redirection when needed.
-	config.RedirectURL = r.URL.String()
+	//	Each visitor has its own session, with its favourites, comparison and filters.
+	sess := session.FromRequest(r)
 
-	//	Fetch the cars from the API
-	carsDataChannel := make(chan []models.Car, 1)
-	errCarsChannel := make(chan error, 1)
-
-	go helpers.FetchCars(carsDataChannel, errCarsChannel)
-
-	carsData := <-carsDataChannel
-	err := <-errCarsChannel
-	close(carsDataChannel)
-	close(errCarsChannel)
-
-	if err != nil {
-		fmt.Println("Error fetching data from the API.")
-		w.WriteHeader(http.StatusInternalServerError)
-		http.Error(w, "Error fetching data from the API.", http.StatusInternalServerError)
-		return
-	}
+	//	Read the cars from the catalog cache, which is kept up to date in the background.
+	carsData := helpers.Catalog.Cars()
 
 	//	Create a small card for each car. Small Card just refers to a variable with sjust few data ot the cars.
-	cards, err := helpers.CreateSmallCardsBatch(carsData)
+	cards, err := helpers.CreateSmallCardsBatch(carsData, sess.State)
 	if err != nil {
 		fmt.Println("Error Creating  cards.")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -63,13 +52,21 @@ func Homepage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	//	Collect the data to be send with the HTML
-	var data models.DataResponse
+	data := NewDataResponse(sess)
 	data.Card = cards
 	data.Categories = categories
 	data.Manufacturers = manufacturers
 	data.Models = dataModels
 	data.NoResults = false
-	data.CompareActive = config.CompareActive
+
+	//	The strip of recently viewed cars shows only the last ones.
+	recent := sess.State.Recent()
+	data.Recent, err = helpers.CreateSmallCardsBatch(helpers.FetchCarsByID(recent[:min(len(recent), RecentStrip)]), sess.State)
+	if err != nil {
+		fmt.Println("Error Creating  cards.")
+		http.Error(w, "Error Creating  cards.", http.StatusInternalServerError)
+		return
+	}
 
 	htmlTemplates := []string{
 		"web/templates/index.html",
@@ -95,53 +92,53 @@ func SelectCar(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	sess := session.FromRequest(r)
+
 	//	Getting QUERY key=map from the URL
 	//	(HTML allows to send this type of query: ?id=id_number to be accessed in the server.
 	//	The query is after the ?. The = symbol divides the key from the value.
 	carID, err := strconv.Atoi(r.URL.Query().Get("id"))
 	if err != nil {
 		fmt.Println("Error selecting car. Could not convert query into Integer: ", err)
+		PageNotFound(w, r, "Sorry, we could not find that car.")
 		return
 	}
 
-	//	We store the current URL to keep track of redirection when needed.
-	config.RedirectURL = r.URL.String()
-
-	//	Fetch the selected car from the API.
-	carDataChannel := make(chan models.Car, 1)
-	errChannel := make(chan error, 1)
-
-	helpers.FetchCar(carID, carDataChannel, errChannel)
-
-	carData := <-carDataChannel
-	err = <-errChannel
-	close(carDataChannel)
-	close(errChannel)
+	//	Read the selected car from the catalog cache.
+	//	A URL with a non-existent car ID responds with the 404 page.
+	carData, err := helpers.Catalog.Car(carID)
 	if err != nil {
-		fmt.Println("Error fetching car from the API.")
-		w.WriteHeader(http.StatusInternalServerError)
-		NotFoundHandler(w, r)
+		CatalogError(w, r, err)
 		return
 	}
 
-	//	We handle the situation where a URL is added with a non-existent car ID by redirecting to main.page.
-	if carID > config.TotalNumCars {
-		http.Redirect(w, r, "/", http.StatusSeeOther)
+	sess.State.AddRecent(carData.Id)
+
+	carNote := models.CarNote{
+		CarId: carData.Id,
+		Note:  sess.State.Note(carData.Id),
+		Tags:  strings.Join(sess.State.Tags(carData.Id), ", "),
 	}
+	renderCarPage(w, sess, carData, carNote, models.ReviewForm{}, "")
+}
 
+// Renders the detail page of the car, with its reviews, the form of its note and tags and the message given.
+// The review form shows the values given, which are kept when they were not valid.
+func renderCarPage(w http.ResponseWriter, sess *session.Session, carData models.Car, carNote models.CarNote, reviewForm models.ReviewForm, message string) {
 	//	Create a big card for the selected car. Big cards refers to a variable including more data than the one included in the small cards.
-	card, err := helpers.CreateBigCard(carData)
-	if err != nil {
-		fmt.Println("Error Creating card.")
-		w.WriteHeader(http.StatusInternalServerError)
-		http.Error(w, "Error Creating card.", http.StatusInternalServerError)
-		return
-	}
+	card := helpers.CreateBigCard(carData, helpers.CatalogLookup(), sess.State)
 
 	//	Create the variable to be sent with the HTML and add the data on it.
-	var data models.DataResponse
+	data := NewDataResponse(sess)
 	data.ExtCard = append(data.ExtCard, card)
-	data.CompareActive = config.CompareActive
+	data.CarNote = carNote
+	data.Message = message
+	data.Reviews = carReviews(carData.Id, sess.Username)
+	//	An empty form shows the review the user already wrote, so it can be edited.
+	if reviewForm == (models.ReviewForm{}) {
+		reviewForm = models.ReviewForm{Rating: data.Reviews.Mine.Rating, Text: data.Reviews.Mine.Text}
+	}
+	data.Reviews.Form = reviewForm
 
 	htmlTemplates := []string{
 		"web/templates/card-page.html",
@@ -150,10 +147,9 @@ func SelectCar(w http.ResponseWriter, r *http.Request) {
 	}
 
 	helpers.RenderTemplate(w, htmlTemplates, "card-page.html", data)
-
 }
 
-// Modifies the FavouritesMap and CompareMap and Responds by redirecting to the URL where it came from.
+// Likes or selects to compare a car, and responds by redirecting to the page where the form was sent from.
 func StatusChange(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path != "/liked-compared" {
 
@@ -168,6 +164,8 @@ func StatusChange(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	sess := session.FromRequest(r)
+
 	if err := r.ParseForm(); err != nil {
 		fmt.Println("Error Parsing Form")
 		http.Error(w, "Bad Request", http.StatusBadRequest)
@@ -177,32 +175,42 @@ func StatusChange(w http.ResponseWriter, r *http.Request) {
 	//	Variables that extract the information from the form that is included in each Card.
 	//	form_id -> the ID of the car that triggered the request.
 	//	trigger -> the information about what button was selected: Favourite or Compare
-	//	redirect_url -> information about the URL where the user clicked the button when submitted the form.
+	//	return_to -> optional, the page to go back to. Without it, the Referer header is used.
 	carId, err := strconv.Atoi(r.Form.Get("form_id"))
 	if err != nil {
 		fmt.Println("Error converting form_id.")
-		w.WriteHeader(http.StatusInternalServerError)
-		http.Error(w, "Error converting form_id.", http.StatusInternalServerError)
+		http.Error(w, "Bad Request", http.StatusBadRequest)
 		return
 	}
 	triggeredButton := r.Form.Get("trigger")
 
-	//	Check what button from the form was selected and change the corresponding map.
+	//	Only cars of the catalog can be liked or compared.
+	if _, err := helpers.Catalog.Car(carId); err != nil {
+		CatalogError(w, r, err)
+		return
+	}
+
+	//	Check what button from the form was selected and change the corresponding state.
 	if triggeredButton == "favorite" {
-		helpers.ModifyFavouritesMap(carId)
+		sess.State.ToggleFavourite(carId)
 	} else if triggeredButton == "compare" {
-		helpers.ModifyComparisonMap(carId)
+		if _, err := sess.State.ToggleCompare(carId); err != nil {
+			fmt.Println("Error selecting the car to compare: ", err)
+			http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
 	} else {
-		http.Redirect(w, r, config.RedirectURL, http.StatusInternalServerError)
+		fmt.Println("Error. Unknown trigger: ", triggeredButton)
+		http.Error(w, "Bad Request", http.StatusBadRequest)
 		return
 	}
 
-	//	Redirect the client to the URL where the Request came from.
-	http.Redirect(w, r, config.RedirectURL, http.StatusSeeOther)
+	//	Redirect the client to the page where the Request came from, or to the page of the car.
+	http.Redirect(w, r, returnURL(r, "/id?id="+strconv.Itoa(carId)), http.StatusSeeOther)
 
 }
 
-// Responds with the compare page including the cars selected to be compared.
+// Saves the cars selected to be compared in the history, and redirects to the page of their comparison.
 func ComparePage(w http.ResponseWriter, r *http.Request) {
 
 	if r.URL.Path != "/comparePage" {
@@ -216,63 +224,83 @@ func ComparePage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	currentURL := r.URL.String()
-	var comparedCars []models.Car
-	var err error
+	sess := session.FromRequest(r)
 
-	//	Collect the cars from different maps, depending if the request comes from "Last comparison" button or "Compare button".
-	// 	Checking the URL is done to allow user "like" a car from the comparison page as well.
-	if config.RedirectURL != currentURL {
+	//	The selection becomes a comparison of the history and is cleared in one step.
+	selectedCars := sess.State.SnapshotLastCompare()
 
-		comparedCars, err = helpers.FetchComparedCars(config.ComparisonMap)
-		if err != nil {
-			fmt.Println("Error fetching data from the API.")
-			w.WriteHeader(http.StatusInternalServerError)
-			NotFoundHandler(w, r)
-			return
-		}
-		helpers.CreateLastCompareMap()
-		helpers.ClearComparisonMap()
-		config.RedirectURL = r.URL.String()
+	//	Check that actually there are some cars to be compared. Otherwise, redirect to main page.
+	if len(selectedCars) < 2 {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, helpers.CompareURL(selectedCars), http.StatusSeeOther)
+}
+
+// Responds with the comparison of the cars in the URL, like /compare?ids=1,4,7.
+// The page only depends on the URL, so it can be bookmarked or shared.
+func Compare(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/compare" {
+		fmt.Println("Error. Path Not Allowed. Compare")
+		http.Error(w, "404 Not Found", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method is not allowed", http.StatusMethodNotAllowed)
+		return
+	}
 
-	} else if config.RedirectURL == currentURL {
+	sess := session.FromRequest(r)
 
-		comparedCars, err = helpers.FetchComparedCars(config.LastCompare)
-		if err != nil {
-			fmt.Println("Error fetching compared cars.")
-			w.WriteHeader(http.StatusInternalServerError)
-			NotFoundHandler(w, r)
-			return
-		}
+	carIDs, err := helpers.ParseCompareIDs(r.URL.Query().Get("ids"))
+	if err != nil {
+		fmt.Println("Error reading the cars to compare: ", err)
+		http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	//	Check that actually there are some cars to be display. Otherwise, redirect to main page.
-	if len(comparedCars) < 2 {
-		http.Redirect(w, r, "/", http.StatusSeeOther)
-	} else {
-		//	Create Big Card for each car.
-		cards, err := helpers.CreateBigCardsBatch(comparedCars)
+	renderComparison(w, r, sess, carIDs, models.SavedComparison{}, "")
+}
+
+// Renders the comparison of the cars with the IDs given, with the title and notes of the
+// "Save & share" form and the message given.
+func renderComparison(w http.ResponseWriter, r *http.Request, sess *session.Session, carIDs []int, form models.SavedComparison, message string) {
+	var comparedCars []models.Car
+	for _, carID := range carIDs {
+		car, err := helpers.Catalog.Car(carID)
 		if err != nil {
-			fmt.Println("Error creating cards.")
-			w.WriteHeader(http.StatusInternalServerError)
-			http.Error(w, "Error creating cards.", http.StatusInternalServerError)
+			CatalogError(w, r, err)
 			return
 		}
+		comparedCars = append(comparedCars, car)
+	}
 
-		//	Create a variable to be sent together with the HTML.
-		//	Add the data from the cars on it
-		var data models.DataResponse
-		data.ExtCard = cards
-		data.CompareActive = config.CompareActive
+	//	Create Big Card for each car.
+	cards, err := helpers.CreateBigCardsBatch(comparedCars, sess.State)
+	if err != nil {
+		fmt.Println("Error creating cards.")
+		w.WriteHeader(http.StatusInternalServerError)
+		http.Error(w, "Error creating cards.", http.StatusInternalServerError)
+		return
+	}
 
-		htmlTemplates := []string{
-			"web/templates/card-page.html",
-			"web/templates/main-bar.html",
-			"web/templates/card-template.html",
-		}
+	//	Create a variable to be sent together with the HTML.
+	//	Add the data from the cars on it, and the link to share the comparison.
+	data := NewDataResponse(sess)
+	data.ExtCard = cards
+	data.ShareURL = absoluteURL(r, helpers.CompareURL(carIDs))
+	data.CompareIDs = helpers.JoinIDs(carIDs)
+	data.Saved = form
+	data.Message = message
 
-		helpers.RenderTemplate(w, htmlTemplates, "card-page.html", data)
+	htmlTemplates := []string{
+		"web/templates/card-page.html",
+		"web/templates/main-bar.html",
+		"web/templates/card-template.html",
 	}
+
+	helpers.RenderTemplate(w, htmlTemplates, "card-page.html", data)
 }
 
 // Responds with a page including all the cars that have been liked.
@@ -288,10 +316,9 @@ func FavouritesPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// We store the current URL
-	config.RedirectURL = r.URL.String()
+	sess := session.FromRequest(r)
 
-	favouriteCars, err := helpers.FetchFavouriteCars()
+	favouriteCars, err := helpers.FetchFavouriteCars(sess.State)
 	if err != nil {
 		fmt.Println("Error fetching data from the API.")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -300,10 +327,10 @@ func FavouritesPage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if len(favouriteCars) == 0 {
-		NoResultsCardPage(w)
+		NoResultsCardPage(w, sess)
 	} else {
 		//	Create Big Card for each car.
-		cards, err := helpers.CreateBigCardsBatch(favouriteCars)
+		cards, err := helpers.CreateBigCardsBatch(favouriteCars, sess.State)
 		if err != nil {
 			fmt.Println("Error creating cards.")
 			w.WriteHeader(http.StatusInternalServerError)
@@ -312,10 +339,10 @@ func FavouritesPage(w http.ResponseWriter, r *http.Request) {
 		}
 
 		//	Create a variable to be sent together with the HTML.
-		//	Add the data from the car/s on it
-		var data models.DataResponse
+		//	Add the data from the car/s on it, and the links to export and import them.
+		data := NewDataResponse(sess)
 		data.ExtCard = cards
-		data.CompareActive = config.CompareActive
+		data.ShowTransfer = true
 
 		htmlTemplates := []string{
 			"web/templates/card-page.html",
@@ -327,8 +354,9 @@ func FavouritesPage(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// Responds with the index page but without any cars. A message "0 results found" instead will be shown.
-func NoResultsIndex(w http.ResponseWriter) {
+// Responds with the index page but without any cars. A message "0 results found" instead will be shown,
+// with the search written, the search suggested and the message of the search form given.
+func NoResultsIndex(w http.ResponseWriter, sess *session.Session, searchForm models.SearchForm) {
 
 	manufacturers, categories, dataModels, err := helpers.FetchManCatMod()
 	if err != nil {
@@ -338,12 +366,12 @@ func NoResultsIndex(w http.ResponseWriter) {
 		return
 	}
 
-	var data models.DataResponse
+	data := NewDataResponse(sess)
 	data.Categories = categories
 	data.Manufacturers = manufacturers
 	data.Models = dataModels
 	data.NoResults = true
-	data.CompareActive = config.CompareActive
+	data.Search = searchForm
 
 	htmlTemplates := []string{
 		"web/templates/index.html",
@@ -356,10 +384,9 @@ func NoResultsIndex(w http.ResponseWriter) {
 }
 
 // Responds with the card-page but without any cars. A message "0 results found" instead will be shown.
-func NoResultsCardPage(w http.ResponseWriter) {
-	var data models.DataResponse
+func NoResultsCardPage(w http.ResponseWriter, sess *session.Session) {
+	data := NewDataResponse(sess)
 	data.NoResults = true
-	data.CompareActive = config.CompareActive
 
 	htmlTemplates := []string{
 		"web/templates/card-page.html",
@@ -383,12 +410,11 @@ func LastCompare(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// We store the current URL
-	config.RedirectURL = r.URL.String()
+	sess := session.FromRequest(r)
 
 	//	FetchComparedCars collects all the cars marked to be Compared
 	//	and stores them in comparedCars variable.
-	comparedCars, err := helpers.FetchComparedCars(config.LastCompare)
+	comparedCars, err := helpers.FetchComparedCars(sess.State.LastCompare())
 	if err != nil {
 		fmt.Println("Error finding last compared cars: ", err)
 		NotFoundHandler(w, r)
@@ -396,10 +422,10 @@ func LastCompare(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if len(comparedCars) == 0 {
-		NoResultsCardPage(w)
+		NoResultsCardPage(w, sess)
 	} else {
 		//	Create Big Card for each car.
-		cards, err := helpers.CreateBigCardsBatch(comparedCars)
+		cards, err := helpers.CreateBigCardsBatch(comparedCars, sess.State)
 		if err != nil {
 			fmt.Println("Error creating cards.")
 			w.WriteHeader(http.StatusInternalServerError)
@@ -409,9 +435,8 @@ func LastCompare(w http.ResponseWriter, r *http.Request) {
 
 		//	Create a variable to be sent together with the HTML.
 		//	Add the data from the car/s on it
-		var data models.DataResponse
+		data := NewDataResponse(sess)
 		data.ExtCard = cards
-		data.CompareActive = config.CompareActive
 
 		htmlTemplates := []string{
 			"web/templates/card-page.html",
@@ -436,15 +461,15 @@ func Filter(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	sess := session.FromRequest(r)
+
 	if err := r.ParseForm(); err != nil {
 		fmt.Println("Error Parsing Form")
 		http.Error(w, "Bad Request", http.StatusBadRequest)
 		return
 	}
 
-	helpers.ClearAllFilters()
-
-	config.RedirectURL = r.URL.String()
+	sess.State.SetFilters(state.Filters{})
 
 	//	Get the Form input. This allows us to know what button triggered the submission.
 	action := r.FormValue("action")
@@ -456,19 +481,31 @@ func Filter(w http.ResponseWriter, r *http.Request) {
 		query := r.FormValue("searchRequest")
 
 		if query != "" {
+			//	The search can have filters, like hp>=300 or -manufacturer:Ford.
+			//	A search that cannot be read is shown again with what is wrong.
+			searchForm := models.SearchForm{Query: query}
 			filteredCars, err := helpers.SearchQueryCars(query)
+			if errors.Is(err, search.ErrInvalidQuery) {
+				searchForm.Message = err.Error()
+				w.WriteHeader(http.StatusBadRequest)
+				NoResultsIndex(w, sess, searchForm)
+				return
+			}
 			if err != nil {
 				fmt.Println("Error filtering data.")
 				w.WriteHeader(http.StatusInternalServerError)
 				NotFoundHandler(w, r)
 				return
 			}
+			//	A query with words that are in no car gets a "did you mean" with the closest ones.
+			searchForm.Suggestion = helpers.SearchSuggestion(query)
+
 			//	Check the number of cars fetched to determine whether we display a
 			//	"0 results found" or not.
 			if len(filteredCars) == 0 {
-				NoResultsIndex(w)
+				NoResultsIndex(w, sess, searchForm)
 			} else {
-				cards, err := helpers.CreateSmallCardsBatch(filteredCars)
+				cards, err := helpers.CreateSmallCardsBatch(filteredCars, sess.State)
 				if err != nil {
 					fmt.Println("Error creating cards.")
 					w.WriteHeader(http.StatusInternalServerError)
@@ -486,12 +523,12 @@ func Filter(w http.ResponseWriter, r *http.Request) {
 
 				//	Create a variable to be sent together with the HTML.
 				//	Add the data from the car/s on it
-				var data models.DataResponse
+				data := NewDataResponse(sess)
 				data.Card = cards
 				data.Categories = categories
 				data.Manufacturers = manufacturers
 				data.Models = dataModels
-				data.CompareActive = config.CompareActive
+				data.Search = searchForm
 
 				htmlTemplates := []string{
 					"web/templates/index.html",
@@ -511,11 +548,38 @@ func Filter(w http.ResponseWriter, r *http.Request) {
 		selectedManufacturers := r.Form["manufacturer"]
 		selectedCategories := r.Form["category"]
 		selectedModels := r.Form["model"]
+		selectedTags := r.Form["tag"]
 
-		helpers.ModifyAllFilterMaps(selectedManufacturers, selectedCategories, selectedModels)
+		filters, err := helpers.ParseFilters(selectedManufacturers, selectedCategories, selectedModels, selectedTags)
+		if err != nil {
+			fmt.Println("Error parsing filters: ", err)
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+
+		//	The bounds are sent back as written, so the form keeps them,
+		//	and ranges that cannot be read are shown again with what is wrong.
+		searchForm := models.SearchForm{
+			YearMin:       r.Form.Get("year_min"),
+			YearMax:       r.Form.Get("year_max"),
+			HorsepowerMin: r.Form.Get("hp_min"),
+			HorsepowerMax: r.Form.Get("hp_max"),
+		}
+		filters.Year, err = helpers.ParseRange("year", searchForm.YearMin, searchForm.YearMax, helpers.MinYear, helpers.MaxYear)
+		if err == nil {
+			filters.Horsepower, err = helpers.ParseRange("horsepower", searchForm.HorsepowerMin, searchForm.HorsepowerMax,
+				helpers.MinHorsepower, helpers.MaxHorsepower)
+		}
+		if err != nil {
+			searchForm.Message = err.Error()
+			w.WriteHeader(http.StatusBadRequest)
+			NoResultsIndex(w, sess, searchForm)
+			return
+		}
+		sess.State.SetFilters(filters)
 
 		//	We fetch the filtered Cars
-		filteredCars, err := helpers.FetchFilteredCars()
+		filteredCars, err := helpers.FetchFilteredCars(sess.State.Filters(), sess.State)
 		if err != nil {
 			fmt.Println("Error filtering data.")
 			w.WriteHeader(http.StatusInternalServerError)
@@ -525,10 +589,10 @@ func Filter(w http.ResponseWriter, r *http.Request) {
 
 		//If no filteredCars -> Print: No results page
 		if len(filteredCars) == 0 {
-			NoResultsIndex(w)
+			NoResultsIndex(w, sess, searchForm)
 		} else {
 			//	Create for each car a small card.
-			cards, err := helpers.CreateSmallCardsBatch(filteredCars)
+			cards, err := helpers.CreateSmallCardsBatch(filteredCars, sess.State)
 			if err != nil {
 				fmt.Println("Error creating cards.")
 				w.WriteHeader(http.StatusInternalServerError)
@@ -547,12 +611,12 @@ func Filter(w http.ResponseWriter, r *http.Request) {
 
 			//	Create a variable to be sent together with the HTML.
 			//	Add the data from the car/s on it
-			var data models.DataResponse
+			data := NewDataResponse(sess)
 			data.Card = cards
 			data.Categories = categories
 			data.Manufacturers = manufacturers
 			data.Models = dataModels
-			data.CompareActive = config.CompareActive
+			data.Search = searchForm
 
 			htmlTemplates := []string{
 				"web/templates/index.html",
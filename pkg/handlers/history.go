@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"cars/pkg/helpers"
+	"cars/pkg/session"
+	"cars/pkg/state"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+var historyTemplates = []string{
+	"web/templates/history.html",
+	"web/templates/main-bar.html",
+}
+
+// Responds with the history of comparisons, and deletes a comparison from it when its form is sent.
+func HistoryPage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/history" {
+		fmt.Println("Error. Path Not Allowed. History")
+		http.Error(w, "404 Not Found", http.StatusNotFound)
+		return
+	}
+
+	sess := session.FromRequest(r)
+
+	switch r.Method {
+	case http.MethodGet:
+		data := NewDataResponse(sess)
+		data.History = helpers.CreateHistory(sess.State.History())
+		helpers.RenderTemplate(w, historyTemplates, "history.html", data)
+
+	case http.MethodPost:
+		if err := r.ParseForm(); err != nil {
+			fmt.Println("Error Parsing Form")
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		if r.Form.Get("trigger") != "delete" {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		id, err := strconv.Atoi(r.Form.Get("comparison_id"))
+		if err != nil {
+			fmt.Println("Error converting comparison_id.")
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+
+		err = sess.State.DeleteComparison(id)
+		if errors.Is(err, state.ErrComparisonNotFound) {
+			PageNotFound(w, r, "Sorry, we could not find that comparison.")
+			return
+		}
+		http.Redirect(w, r, "/history", http.StatusSeeOther)
+
+	default:
+		w.Header().Set("Allow", http.MethodGet+", "+http.MethodPost)
+		http.Error(w, "Method is not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Opens again a comparison of the history.
+func ComparisonPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method is not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sess := session.FromRequest(r)
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		PageNotFound(w, r, "Sorry, we could not find that comparison.")
+		return
+	}
+	comparison, ok := sess.State.Comparison(id)
+	if !ok {
+		PageNotFound(w, r, "Sorry, we could not find that comparison.")
+		return
+	}
+
+	comparedCars, err := helpers.FetchComparedCars(comparison.Cars)
+	if err != nil {
+		fmt.Println("Error finding compared cars: ", err)
+		NotFoundHandler(w, r)
+		return
+	}
+
+	if len(comparedCars) == 0 {
+		NoResultsCardPage(w, sess)
+		return
+	}
+
+	cards, err := helpers.CreateBigCardsBatch(comparedCars, sess.State)
+	if err != nil {
+		fmt.Println("Error creating cards.")
+		http.Error(w, "Error creating cards.", http.StatusInternalServerError)
+		return
+	}
+
+	data := NewDataResponse(sess)
+	data.ExtCard = cards
+
+	htmlTemplates := []string{
+		"web/templates/card-page.html",
+		"web/templates/main-bar.html",
+		"web/templates/card-template.html",
+	}
+	helpers.RenderTemplate(w, htmlTemplates, "card-page.html", data)
+}
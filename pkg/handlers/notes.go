@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"cars/pkg/helpers"
+	"cars/pkg/models"
+	"cars/pkg/session"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Saves the private note and the tags of a car, sent from its detail page.
+// The tags are written separated by commas.
+func SaveNote(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/notes" {
+		fmt.Println("Error. Path Not Allowed. SaveNote")
+		http.Error(w, "404 Not Found", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method is not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sess := session.FromRequest(r)
+
+	if err := r.ParseForm(); err != nil {
+		fmt.Println("Error Parsing Form")
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	carID, err := strconv.Atoi(r.Form.Get("form_id"))
+	if err != nil {
+		fmt.Println("Error converting form_id.")
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	carData, err := helpers.Catalog.Car(carID)
+	if err != nil {
+		CatalogError(w, r, err)
+		return
+	}
+
+	var tags []string
+	for _, tag := range strings.Split(r.Form.Get("tags"), ",") {
+		if strings.TrimSpace(tag) != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	err = sess.State.SetNote(carID, r.Form.Get("note"), tags)
+	if err != nil {
+		//	The form keeps what was written, so it can be corrected.
+		w.WriteHeader(http.StatusBadRequest)
+		form := models.CarNote{CarId: carID, Note: r.Form.Get("note"), Tags: r.Form.Get("tags")}
+		renderCarPage(w, sess, carData, form, models.ReviewForm{}, err.Error())
+		return
+	}
+
+	http.Redirect(w, r, "/id?id="+strconv.Itoa(carID), http.StatusSeeOther)
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"cars/pkg/helpers"
+	"cars/pkg/session"
+	"fmt"
+	"net/http"
+)
+
+// RecentStrip is the number of recently viewed cars shown in the homepage.
+const RecentStrip = 6
+
+var recentTemplates = []string{
+	"web/templates/recent.html",
+	"web/templates/main-bar.html",
+	"web/templates/card-template.html",
+}
+
+// Responds with the cars viewed recently by the visitor, and empties the list when its form is sent.
+func RecentPage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/recent" {
+		fmt.Println("Error. Path Not Allowed. Recent")
+		http.Error(w, "404 Not Found", http.StatusNotFound)
+		return
+	}
+
+	sess := session.FromRequest(r)
+
+	switch r.Method {
+	case http.MethodGet:
+		cards, err := helpers.CreateSmallCardsBatch(helpers.FetchCarsByID(sess.State.Recent()), sess.State)
+		if err != nil {
+			fmt.Println("Error creating cards.")
+			http.Error(w, "Error creating cards.", http.StatusInternalServerError)
+			return
+		}
+		data := NewDataResponse(sess)
+		data.Recent = cards
+		helpers.RenderTemplate(w, recentTemplates, "recent.html", data)
+
+	case http.MethodPost:
+		if err := r.ParseForm(); err != nil {
+			fmt.Println("Error Parsing Form")
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		if r.Form.Get("trigger") != "clear" {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		sess.State.ClearRecent()
+		http.Redirect(w, r, "/recent", http.StatusSeeOther)
+
+	default:
+		w.Header().Set("Allow", http.MethodGet+", "+http.MethodPost)
+		http.Error(w, "Method is not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// returnPaths are the pages a form can send the visitor back to after a change.
+var returnPaths = []string{
+	"/", "/id", "/search", "/compare", "/lastCompare", "/favouritePage",
+	"/collections", "/history", "/recent", "/account",
+}
+
+// returnPrefixes are the pages with a path value, like /collections/3, a form can send the visitor back to.
+var returnPrefixes = []string{"/c/", "/collections/", "/history/"}
+
+// Returns the page where the form of the request was sent from, to go back to it after a change.
+// It is read from the return_to field of the form or, when the form has none, from the Referer header.
+// Only the pages of this site in returnPaths are allowed: for anything else, fallback is returned.
+func returnURL(r *http.Request, fallback string) string {
+	if target := r.Form.Get("return_to"); target != "" {
+		if safe, ok := safeReturn(target, ""); ok {
+			return safe
+		}
+		return fallback
+	}
+	if safe, ok := safeReturn(r.Referer(), r.Host); ok {
+		return safe
+	}
+	return fallback
+}
+
+// Checks that target is one of the pages a visitor can be sent back to, and returns its path and query.
+// A target with a scheme or a host is only allowed when host is given and it is the same.
+func safeReturn(target, host string) (string, bool) {
+	//	Browsers read a backslash as a slash, so /\evil.com would leave the site.
+	if target == "" || strings.Contains(target, `\`) {
+		return "", false
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.User != nil || u.Opaque != "" {
+		return "", false
+	}
+	if u.Scheme != "" || u.Host != "" {
+		if host == "" || u.Host != host || (u.Scheme != "http" && u.Scheme != "https") {
+			return "", false
+		}
+	}
+	if !allowedReturnPath(u.Path) {
+		return "", false
+	}
+
+	//	The path is written back escaped, so an encoded ? or control character stays in the path.
+	safe := u.EscapedPath()
+	if u.RawQuery != "" {
+		safe += "?" + u.RawQuery
+	}
+	return safe, true
+}
+
+// Reports whether the path is in returnPaths, or is a page with one path value after a returnPrefixes.
+func allowedReturnPath(path string) bool {
+	for _, allowed := range returnPaths {
+		if path == allowed {
+			return true
+		}
+	}
+	for _, prefix := range returnPrefixes {
+		value, found := strings.CutPrefix(path, prefix)
+		if found && value != "" && !strings.Contains(value, "/") {
+			return true
+		}
+	}
+	return false
+}
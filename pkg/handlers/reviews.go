@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"cars/pkg/helpers"
+	"cars/pkg/models"
+	"cars/pkg/reviews"
+	"cars/pkg/session"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var moderationTemplates = []string{
+	"web/templates/moderation.html",
+	"web/templates/main-bar.html",
+}
+
+// Saves the rating and the review of a car written by the user logged in, sent from its detail page.
+// The review is shown to everyone once a moderator approves it.
+func SaveReview(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/reviews" {
+		fmt.Println("Error. Path Not Allowed. SaveReview")
+		http.Error(w, "404 Not Found", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method is not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sess := session.FromRequest(r)
+
+	if err := r.ParseForm(); err != nil {
+		fmt.Println("Error Parsing Form")
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	carID, err := strconv.Atoi(r.Form.Get("form_id"))
+	if err != nil {
+		fmt.Println("Error converting form_id.")
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	carData, err := helpers.Catalog.Car(carID)
+	if err != nil {
+		CatalogError(w, r, err)
+		return
+	}
+	carNote := models.CarNote{
+		CarId: carID,
+		Note:  sess.State.Note(carID),
+		Tags:  strings.Join(sess.State.Tags(carID), ", "),
+	}
+
+	//	A missing or wrong rating is left as 0, and reported by the store.
+	rating, _ := strconv.Atoi(r.Form.Get("rating"))
+	form := models.ReviewForm{Rating: rating, Text: r.Form.Get("text")}
+
+	if sess.Username == "" {
+		form.Message = "Log in to write a review."
+		w.WriteHeader(http.StatusUnauthorized)
+		renderCarPage(w, sess, carData, carNote, form, "")
+		return
+	}
+
+	_, err = helpers.Reviews.Submit(carID, sess.Username, rating, form.Text)
+	if err != nil {
+		//	The form keeps what was written, so it can be corrected.
+		form.Message = err.Error()
+		w.WriteHeader(http.StatusBadRequest)
+		renderCarPage(w, sess, carData, carNote, form, "")
+		return
+	}
+
+	http.Redirect(w, r, "/id?id="+strconv.Itoa(carID), http.StatusSeeOther)
+}
+
+// Responds with the reviews waiting for moderation, and approves or rejects one when its form is sent.
+// Only the moderators can see this page.
+func ModerationPage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/reviews/moderation" {
+		fmt.Println("Error. Path Not Allowed. Moderation")
+		http.Error(w, "404 Not Found", http.StatusNotFound)
+		return
+	}
+
+	sess := session.FromRequest(r)
+
+	if sess.Username == "" {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+	if !helpers.IsModerator(sess.Username) {
+		fmt.Println("Error. Not a moderator: ", sess.Username)
+		http.Error(w, "403 Forbidden", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		renderModeration(w, sess, "")
+
+	case http.MethodPost:
+		if err := r.ParseForm(); err != nil {
+			fmt.Println("Error Parsing Form")
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		var approve bool
+		switch r.Form.Get("trigger") {
+		case "approve":
+			approve = true
+		case "reject":
+			approve = false
+		default:
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		id, err := strconv.Atoi(r.Form.Get("review_id"))
+		if err != nil {
+			fmt.Println("Error converting review_id.")
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+
+		//	A review written again has a new ID, so the one read is not moderated
+		//	and the list is shown again with its new text.
+		err = helpers.Reviews.Moderate(id, approve)
+		if errors.Is(err, reviews.ErrNotFound) {
+			w.WriteHeader(http.StatusConflict)
+			renderModeration(w, sess, "That review was written again or removed by its author. Read it again before moderating it.")
+			return
+		}
+		http.Redirect(w, r, "/reviews/moderation", http.StatusSeeOther)
+
+	default:
+		w.Header().Set("Allow", http.MethodGet+", "+http.MethodPost)
+		http.Error(w, "Method is not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Renders the reviews waiting for moderation, with the message given.
+func renderModeration(w http.ResponseWriter, sess *session.Session, message string) {
+	data := NewDataResponse(sess)
+	data.PendingReviews = helpers.CreateReviews(helpers.Reviews.Pending())
+	data.Message = message
+	helpers.RenderTemplate(w, moderationTemplates, "moderation.html", data)
+}
+
+// Creates the reviews of the car for its detail page: the approved ones, their average,
+// and the review of the user logged in whatever its status.
+func carReviews(carID int, username string) models.CarReviews {
+	result := models.CarReviews{CarId: carID}
+	result.Average, result.Count = helpers.Reviews.Rating(carID)
+	result.List = helpers.CreateReviews(helpers.Reviews.Approved(carID))
+	if username != "" {
+		if mine, ok := helpers.Reviews.ByAuthor(carID, username); ok {
+			result.Mine = helpers.CreateReview(mine)
+		}
+	}
+	return result
+}
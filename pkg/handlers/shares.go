@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"cars/pkg/helpers"
+	"cars/pkg/models"
+	"cars/pkg/session"
+	"cars/pkg/shares"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Saves the comparison sent by the "Save & share" form, and redirects to its short link.
+func SaveComparison(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/c" {
+		fmt.Println("Error. Path Not Allowed. SaveComparison")
+		http.Error(w, "404 Not Found", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method is not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		fmt.Println("Error Parsing Form")
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	sess := session.FromRequest(r)
+
+	carIDs, err := helpers.ParseCompareIDs(r.Form.Get("ids"))
+	if err != nil {
+		fmt.Println("Error reading the cars to compare: ", err)
+		http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	//	Only cars of the catalog can be saved.
+	for _, carID := range carIDs {
+		if _, err := helpers.Catalog.Car(carID); err != nil {
+			CatalogError(w, r, err)
+			return
+		}
+	}
+
+	title, notes := r.Form.Get("title"), r.Form.Get("notes")
+	saved, err := helpers.Shares.Save(title, notes, carIDs)
+	if errors.Is(err, shares.ErrInvalidTitle) || errors.Is(err, shares.ErrNotesTooLong) {
+		//	The comparison is shown again with what was written, to fix it.
+		w.WriteHeader(http.StatusBadRequest)
+		renderComparison(w, r, sess, carIDs, models.SavedComparison{Title: title, Notes: notes}, err.Error())
+		return
+	}
+	if err != nil {
+		fmt.Println("Error saving comparison: ", err)
+		ErrorPage(w, r, http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/c/"+saved.Code, http.StatusSeeOther)
+}
+
+// Responds with the comparison saved with the code of the URL, like /c/Ab3xK.
+func SavedComparison(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method is not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sess := session.FromRequest(r)
+
+	saved, ok := helpers.Shares.View(r.PathValue("code"))
+	if !ok {
+		PageNotFound(w, r, "Sorry, we could not find that comparison.")
+		return
+	}
+
+	//	Cars removed from the catalog after saving are left out.
+	cards, err := helpers.CreateBigCardsBatch(helpers.FetchCarsByID(saved.Cars), sess.State)
+	if err != nil {
+		fmt.Println("Error creating cards.")
+		http.Error(w, "Error creating cards.", http.StatusInternalServerError)
+		return
+	}
+
+	data := NewDataResponse(sess)
+	data.ExtCard = cards
+	data.NoResults = len(cards) == 0
+	data.ShareURL = absoluteURL(r, "/c/"+saved.Code)
+	data.Saved = models.SavedComparison{
+		Code:       saved.Code,
+		Title:      saved.Title,
+		Notes:      saved.Notes,
+		Created:    saved.Created.Format("2 January 2006, 15:04"),
+		LastViewed: saved.LastViewed.Format("2 January 2006, 15:04"),
+	}
+
+	htmlTemplates := []string{
+		"web/templates/card-page.html",
+		"web/templates/main-bar.html",
+		"web/templates/card-template.html",
+	}
+	helpers.RenderTemplate(w, htmlTemplates, "card-page.html", data)
+}
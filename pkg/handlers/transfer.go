@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"cars/pkg/helpers"
+	"cars/pkg/models"
+	"cars/pkg/session"
+	"cars/pkg/state"
+	"cars/pkg/transfer"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// MaxImportSize is the maximum size of a file to import.
+const MaxImportSize = 1 << 20
+
+var transferTemplates = []string{
+	"web/templates/transfer.html",
+	"web/templates/main-bar.html",
+}
+
+// Responds with a file with the favourites and the collections of the user, as JSON or CSV.
+func ExportFavourites(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/favourites/export" {
+		fmt.Println("Error. Path Not Allowed. ExportFavourites")
+		http.Error(w, "404 Not Found", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method is not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sess := session.FromRequest(r)
+
+	favourites, err := helpers.CreateBigCardsBatch(helpers.FetchCarsByID(sess.State.Favourites()), sess.State)
+	if err != nil {
+		fmt.Println("Error creating cards.")
+		http.Error(w, "Error creating cards.", http.StatusInternalServerError)
+		return
+	}
+	doc := transfer.Document{Favourites: favourites}
+	for _, collection := range sess.State.Collections() {
+		cards, err := helpers.CreateBigCardsBatch(helpers.FetchCarsByID(collection.Cars), sess.State)
+		if err != nil {
+			fmt.Println("Error creating cards.")
+			http.Error(w, "Error creating cards.", http.StatusInternalServerError)
+			return
+		}
+		doc.Collections = append(doc.Collections, transfer.Collection{Name: collection.Name, Cars: cards})
+	}
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", `attachment; filename="favourites.json"`)
+		err = transfer.WriteJSON(w, doc)
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="favourites.csv"`)
+		err = transfer.WriteCSV(w, doc)
+	default:
+		http.Error(w, "Bad Request: unknown format "+strconv.Quote(format), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		fmt.Println("Error exporting favourites: ", err)
+	}
+}
+
+// Responds with the page to export and import favourites, and imports the file sent with its form.
+// The file can be merged with the favourites of the user, or replace them.
+func ImportFavourites(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/favourites/import" {
+		fmt.Println("Error. Path Not Allowed. ImportFavourites")
+		http.Error(w, "404 Not Found", http.StatusNotFound)
+		return
+	}
+
+	sess := session.FromRequest(r)
+
+	switch r.Method {
+	case http.MethodGet:
+		helpers.RenderTemplate(w, transferTemplates, "transfer.html", NewDataResponse(sess))
+
+	case http.MethodPost:
+		r.Body = http.MaxBytesReader(w, r.Body, MaxImportSize)
+		err := r.ParseMultipartForm(MaxImportSize)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			renderTransfer(w, sess, http.StatusRequestEntityTooLarge, "The file is larger than 1 MB.")
+			return
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			renderTransfer(w, sess, http.StatusBadRequest, "Choose a JSON or CSV file of up to 1 MB to import.")
+			return
+		}
+		defer file.Close()
+
+		doc, err := transfer.Read(file)
+		if err != nil {
+			renderTransfer(w, sess, http.StatusBadRequest, "The file could not be read: "+err.Error())
+			return
+		}
+
+		favourites, collections, report := validateImport(doc)
+		if r.FormValue("mode") == "replace" {
+			sess.State.RestoreFavourites(favourites, collections)
+		} else {
+			sess.State.Merge(state.Snapshot{Favourites: favourites, Collections: collections})
+		}
+
+		data := NewDataResponse(sess)
+		data.Import = report
+		helpers.RenderTemplate(w, transferTemplates, "transfer.html", data)
+
+	default:
+		w.Header().Set("Allow", http.MethodGet+", "+http.MethodPost)
+		http.Error(w, "Method is not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Keeps the cars of the document that are in the catalog, and the collections with a valid name.
+// Collections with the same name are joined. The entries left out are listed in the report.
+func validateImport(doc transfer.Document) ([]int, []state.Collection, models.ImportReport) {
+	report := models.ImportReport{Done: true}
+
+	known := func(card models.ExtendedCard) bool {
+		if _, err := helpers.Catalog.Car(card.Id); err != nil {
+			entry := "car " + strconv.Itoa(card.Id)
+			if card.Name != "" {
+				entry += " (" + card.Name + ")"
+			}
+			report.Unknown = append(report.Unknown, entry)
+			return false
+		}
+		return true
+	}
+
+	var favourites []int
+	for _, card := range doc.Favourites {
+		if known(card) && !slices.Contains(favourites, card.Id) {
+			favourites = append(favourites, card.Id)
+		}
+	}
+
+	var collections []state.Collection
+	for _, imported := range doc.Collections {
+		name, err := state.CollectionName(imported.Name)
+		if err != nil {
+			report.Unknown = append(report.Unknown, fmt.Sprintf("collection %q: %v", imported.Name, err))
+			continue
+		}
+		var cars []int
+		for _, card := range imported.Cars {
+			if known(card) {
+				cars = append(cars, card.Id)
+			}
+		}
+		//	A collection is left out when none of its cars is in the catalog.
+		if len(imported.Cars) > 0 && len(cars) == 0 {
+			continue
+		}
+
+		i := slices.IndexFunc(collections, func(c state.Collection) bool {
+			return strings.EqualFold(c.Name, name)
+		})
+		if i == -1 {
+			collections = append(collections, state.Collection{Name: name})
+			i = len(collections) - 1
+		}
+		for _, carID := range cars {
+			if !collections[i].Contains(carID) {
+				collections[i].Cars = append(collections[i].Cars, carID)
+			}
+		}
+	}
+
+	report.Favourites = len(favourites)
+	report.Collections = len(collections)
+	return favourites, collections, report
+}
+
+// Renders the export and import page with an error message and the status given.
+func renderTransfer(w http.ResponseWriter, sess *session.Session, status int, message string) {
+	data := NewDataResponse(sess)
+	data.Message = message
+	w.WriteHeader(status)
+	helpers.RenderTemplate(w, transferTemplates, "transfer.html", data)
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"cars/pkg/catalog"
+	"cars/pkg/helpers"
+	"cars/pkg/models"
+	"cars/pkg/state"
+	"cars/pkg/transfer"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Replaces the catalog of the handlers with cars 1 to 3 until the test ends.
+func useTestCatalog(t *testing.T) {
+	t.Helper()
+	var data catalog.Data
+	for id := 1; id <= 3; id++ {
+		data.Cars = append(data.Cars, models.Car{Id: id})
+	}
+	cache := catalog.New(catalog.NewMemorySource(data, ""), time.Hour)
+	if err := cache.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	previous := helpers.Catalog
+	helpers.Catalog = cache
+	t.Cleanup(func() { helpers.Catalog = previous })
+}
+
+func TestValidateImport(t *testing.T) {
+	useTestCatalog(t)
+
+	doc, err := transfer.Read(strings.NewReader("collection,id,name\n" +
+		",1,Corolla\n,9,Unknown car\n,1,Corolla\n" +
+		"Weekend,2,Camry\nweekend,3,\nWeekend,8,\n" +
+		"Gone,7,Old car\n" +
+		"\"" + strings.Repeat("x", state.MaxCollectionName+1) + "\",1,\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	favourites, collections, report := validateImport(doc)
+
+	if !slices.Equal(favourites, []int{1}) {
+		t.Errorf("got favourites %v, want [1]", favourites)
+	}
+	//	Names that only differ in case are one collection, and one without known cars is left out.
+	wantCollections := []state.Collection{{Name: "Weekend", Cars: []int{2, 3}}}
+	if !reflect.DeepEqual(collections, wantCollections) {
+		t.Errorf("got collections %+v, want %+v", collections, wantCollections)
+	}
+
+	wantUnknown := []string{
+		"car 9 (Unknown car)",
+		"car 8",
+		"car 7 (Old car)",
+		`collection "` + strings.Repeat("x", state.MaxCollectionName+1) + `": ` + state.ErrInvalidCollectionName.Error(),
+	}
+	if !slices.Equal(report.Unknown, wantUnknown) {
+		t.Errorf("got unknown entries %q, want %q", report.Unknown, wantUnknown)
+	}
+	if !report.Done || report.Favourites != 1 || report.Collections != 1 {
+		t.Errorf("got report %+v, want 1 favourite and 1 collection", report)
+	}
+}
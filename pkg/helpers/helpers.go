@@ -1,502 +1,225 @@
 package helpers
 
 import (
-	"cars/pkg/config"
+	"cars/pkg/accounts"
+	"cars/pkg/catalog"
 	"cars/pkg/models"
-	"encoding/json"
+	"cars/pkg/reviews"
+	"cars/pkg/shares"
+	"cars/pkg/state"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"slices"
 	"strconv"
-	"sync"
+	"strings"
 )
 
-// Fetch a car from the API by ID.
-func FetchCar(id int, carChannel chan models.Car, errChannel chan error) {
+// Catalog is the in-memory copy of the catalog that the handlers read from.
+// It is created on start up with the source configured by flags or environment variables.
+var Catalog *catalog.Cache
 
-	//	Convert the ID from int to string.
-	idString := strconv.Itoa(id)
+// Accounts are the registered users, with the favourites and comparisons of each of them.
+var Accounts *accounts.Store
 
-	// Attach the ID in string format to the URL to fetch.
-	car, err := http.Get("http://localhost:3000/api/models/" + idString)
-	if err != nil {
-		fmt.Printf("Error getting cars from the API: %v\n", err)
-		carChannel <- models.Car{}
-		errChannel <- err
-		return
-	}
-	defer car.Body.Close()
-
-	//	Write the data in []byte type.
-	data, err := io.ReadAll(car.Body)
-	if err != nil {
-		fmt.Printf("Error reading cars data: %v", err)
-		carChannel <- models.Car{}
-		errChannel <- err
-		return
-	}
-
-	var carData models.Car
-	//	Decode the json data and store it in a variable with same fields as the json data.
-
-	err = json.Unmarshal(data, &carData)
-	if err != nil {
-		fmt.Printf("Error unmarshalling data: %v\n", err)
-		carChannel <- models.Car{}
-		errChannel <- err
-		return
-	}
-
-	carChannel <- carData
-	errChannel <- nil
-}
-
-// Fetch all cars from the API.
-func FetchCars(carsDataChannel chan []models.Car, errChannel chan error) {
-
-	cars, err := http.Get("http://localhost:3000/api/models")
-	if err != nil {
-		fmt.Printf("Error getting cars from the API: %v", err)
-		carsDataChannel <- nil
-		errChannel <- err
-		return
-	}
-	defer cars.Body.Close()
-
-	var carsData []models.Car
-
-	if err = json.NewDecoder(cars.Body).Decode(&carsData); err != nil {
-		fmt.Printf("Error unmarshalling data: %v", err)
-		carsDataChannel <- nil
-		errChannel <- err
-		return
-	}
+// Shares are the comparisons saved to be shared with a short code.
+var Shares *shares.Store
 
-	carsDataChannel <- carsData
-	errChannel <- nil
-}
-
-// Fetch a category from the API by ID.
-func FetchCategory(id int, categoryChannel chan models.Categories, errChannel chan error) {
-
-	idString := strconv.Itoa(id)
-
-	category, err := http.Get("http://localhost:3000/api/categories/" + idString)
-	if err != nil {
-		fmt.Printf("Error getting category from the API: %v\n", err)
-		categoryChannel <- models.Categories{}
-		errChannel <- err
-		return
-	}
-	defer category.Body.Close()
-
-	var categoryData models.Categories
-
-	if err = json.NewDecoder(category.Body).Decode(&categoryData); err != nil {
-		fmt.Printf("Error unmarshalling data: %v", err)
-		categoryChannel <- models.Categories{}
-		errChannel <- err
-		return
-	}
-	categoryChannel <- categoryData
-	errChannel <- nil
-}
+// Reviews are the ratings and reviews of the cars written by the users.
+var Reviews *reviews.Store
 
-// Fetch all categories from the API.
-func FetchCategories(categoriesChannel chan []models.Categories, errChannel chan error) {
+// Moderators are the usernames of the accounts that approve or reject the reviews.
+var Moderators []string
 
-	categories, err := http.Get("http://localhost:3000/api/categories")
-	if err != nil {
-		fmt.Printf("Error getting categories from the API: %v\n", err)
-		categoriesChannel <- nil
-		errChannel <- err
-		close(categoriesChannel)
-		close(errChannel)
-		return
+// Reports whether the user logged in can approve or reject the reviews.
+func IsModerator(username string) bool {
+	if username == "" {
+		return false
 	}
-	defer categories.Body.Close()
-
-	var categoriesData []models.Categories
-
-	if err = json.NewDecoder(categories.Body).Decode(&categoriesData); err != nil {
-		fmt.Printf("Error unmarshalling data: %v", err)
-		categoriesChannel <- nil
-		errChannel <- err
-		close(categoriesChannel)
-		close(errChannel)
-		return
-	}
-	categoriesChannel <- categoriesData
-	errChannel <- nil
-	close(categoriesChannel)
-	close(errChannel)
+	return slices.ContainsFunc(Moderators, func(moderator string) bool {
+		return strings.EqualFold(moderator, username)
+	})
 }
 
-// Fetch a manufacturer from the API by ID.
-func FetchManufacturer(id int, manufacturerChannel chan models.Manufacturers, errChannel chan error) {
-
-	idString := strconv.Itoa(id)
-
-	manufacturer, err := http.Get("http://localhost:3000/api/manufacturers/" + idString)
-	if err != nil {
-		fmt.Printf("Error getting manufacturer from the API: %v\n", err)
-		manufacturerChannel <- models.Manufacturers{}
-		errChannel <- err
-		return
-	}
-	defer manufacturer.Body.Close()
-
-	var manufacturerData models.Manufacturers
-
-	if err = json.NewDecoder(manufacturer.Body).Decode(&manufacturerData); err != nil {
-		fmt.Printf("Error unmarshalling data: %v", err)
-		manufacturerChannel <- models.Manufacturers{}
-		errChannel <- err
-		return
-	}
-	manufacturerChannel <- manufacturerData
-	errChannel <- nil
-}
-
-// Fetch all manufacturers from the API.
-func FetchManufacturers(manufacturersChannel chan []models.Manufacturers, errChannel chan error) {
-
-	manufacturers, err := http.Get("http://localhost:3000/api/manufacturers")
-	if err != nil {
-		fmt.Printf("Error getting manufacturers from the API: %v\n", err)
-		manufacturersChannel <- nil
-		errChannel <- err
-		close(manufacturersChannel)
-		close(errChannel)
-		return
-	}
-	defer manufacturers.Body.Close()
-
-	var manufacturersData []models.Manufacturers
-
-	if err = json.NewDecoder(manufacturers.Body).Decode(&manufacturersData); err != nil {
-		fmt.Printf("Error unmarshalling data: %v", err)
-		manufacturersChannel <- nil
-		errChannel <- err
-		close(manufacturersChannel)
-		close(errChannel)
-		return
-	}
-
-	manufacturersChannel <- manufacturersData
-	errChannel <- nil
-	close(manufacturersChannel)
-	close(errChannel)
-}
-
-// Fetch all models from the API.
-func FetchModels(modelsChannel chan []models.Modelcar, errChannel chan error) {
-
+// Creates the list of models from the cars given.
+func CreateModels(cars []models.Car) []models.Modelcar {
 	var carModels []models.Modelcar
-	var mu sync.Mutex
-
-	carsDataChannel := make(chan []models.Car)
-	carsErrChannel := make(chan error)
-
-	go FetchCars(carsDataChannel, carsErrChannel)
-	cars := <-carsDataChannel
-	err := <-carsErrChannel
-
-	if err != nil {
-		fmt.Printf("Error fetching data from the API")
-		modelsChannel <- nil
-		errChannel <- err
-		return
-	}
-
 	for _, car := range cars {
 		model := models.Modelcar{
 			Id:   car.Id,
 			Name: car.Name,
 		}
-		mu.Lock()
 		carModels = append(carModels, model)
-		mu.Unlock()
 	}
-	modelsChannel <- carModels
-	errChannel <- nil
-	close(modelsChannel)
-	close(errChannel)
+	return carModels
 }
 
-// Fetch all. Manufacturers, Categories and Models.
+// Fetch all. Manufacturers, Categories and Models from the catalog cache.
 func FetchManCatMod() ([]models.Manufacturers, []models.Categories, []models.Modelcar, error) {
-	categoriesChannel := make(chan []models.Categories)
-	errCategoriesChannel := make(chan error)
-	manufacturersChannel := make(chan []models.Manufacturers)
-	errManufacturersChannel := make(chan error)
-	modelsChannel := make(chan []models.Modelcar)
-	errModelsChannel := make(chan error)
-
-	go FetchCategories(categoriesChannel, errCategoriesChannel)
-	go FetchManufacturers(manufacturersChannel, errManufacturersChannel)
-	go FetchModels(modelsChannel, errModelsChannel)
-
-	categories := <-categoriesChannel
-	err := <-errCategoriesChannel
-	if err != nil {
-		fmt.Println("Error fetching categories from the API.")
-		return nil, nil, nil, err
-	}
-
-	manufacturers := <-manufacturersChannel
-	err = <-errManufacturersChannel
-	if err != nil {
-		fmt.Println("Error fetching manufacturers from the API.")
-		return nil, nil, nil, err
-	}
-
-	dataModels := <-modelsChannel
-	err = <-errModelsChannel
-	if err != nil {
-		fmt.Println("Error fetching models from the API.")
-		return nil, nil, nil, err
-	}
+	manufacturers := Catalog.Manufacturers()
+	categories := Catalog.Categories()
+	dataModels := CreateModels(Catalog.Cars())
 	return manufacturers, categories, dataModels, nil
 }
 
-// Fetch only the cars from the API, that follows the CategoriesFilterMap, ManufacturersFilterMap and ModelsFIlterMap variables.
-func FetchFilteredCars() ([]models.Car, error) {
+// Fetch only the cars from the catalog cache, that pass the filters given.
+func FetchFilteredCars(filters state.Filters, visitor *state.Store) ([]models.Car, error) {
 
 	var carsFiltered []models.Car
 
-	carsDataChannel := make(chan []models.Car, 1)
-	errChannel := make(chan error, 1)
-
-	FetchCars(carsDataChannel, errChannel)
-
-	carsData := <-carsDataChannel
-	err := <-errChannel
-	if err != nil {
-		fmt.Println("Error fetching cars from the API.")
-		return nil, err
-	}
-
-	for _, car := range carsData {
-		if config.CategoriesFilterMap[car.CategoryID] && config.ManufacturersFilterMap[car.ManufacturerID] && config.ModelsFilterMap[car.Name] {
+	//	Tags are checked in the state of the visitor, since each one has its own.
+	for _, car := range Catalog.Cars() {
+		if filters.Match(car) && visitor.HasTags(car.Id, filters.Tags) {
 			carsFiltered = append(carsFiltered, car)
 		}
 	}
 	return carsFiltered, nil
 }
 
-// Fetch only the cars from the API, that are indicated in FavouritesMap variable.
-func FetchFavouriteCars() ([]models.Car, error) {
-
-	var favouriteCars []models.Car
-	var carsSelected []int
-
-	//	Get every ID car from Favourites Map.
-	for id, value := range config.FavouritesMap {
-		if !value {
-			continue
-		} else {
-			carsSelected = append(carsSelected, id)
-		}
-	}
+// Fetch only the cars from the catalog cache, that are liked in the state of the visitor.
+func FetchFavouriteCars(visitor *state.Store) ([]models.Car, error) {
+	return FetchCarsByID(visitor.Favourites()), nil
+}
 
-	var err error
-	var car models.Car
-	carsDataChannel := make(chan models.Car, len(carsSelected))
-	errChannel := make(chan error, len(carsSelected))
+// Fetch only the cars from the catalog cache, with the IDs given.
+func FetchComparedCars(carsSelected []int) ([]models.Car, error) {
+	return FetchCarsByID(carsSelected), nil
+}
 
-	//	Range trough every ID Car and Fetch the Car.
+// Returns the cars from the catalog cache with the IDs given, in the same order.
+// IDs that are not in the catalog are skipped.
+func FetchCarsByID(carsSelected []int) []models.Car {
+	var cars []models.Car
 	for _, carId := range carsSelected {
-		go FetchCar(carId, carsDataChannel, errChannel)
-	}
-
-	//	Check all the error messages from the channel and append the cars to the variable.
-	for i := 0; i < len(carsSelected); i++ {
-		err = <-errChannel
-		car = <-carsDataChannel
+		car, err := Catalog.Car(carId)
 		if err != nil {
-			return []models.Car{}, err
-		} else {
-			favouriteCars = append(favouriteCars, car)
+			continue
 		}
+		cars = append(cars, car)
 	}
-	return favouriteCars, nil
-}
-
-// Modify the global variable FavouritesMap.
-func ModifyFavouritesMap(carId int) {
-	config.FavouritesMap[carId] = !config.FavouritesMap[carId]
-
+	return cars
 }
 
-// Fetch only the cars from the API, that are indicated in ComparisonMap variable.
-func FetchComparedCars(compareMap map[int]bool) ([]models.Car, error) {
-	var comparedCars []models.Car
-	var carsSelected []int
+// Creates the filters from the values of the filter form.
+// Zero items selected in a list equals to all items of that list selected.
+func ParseFilters(selectedManufacturers, selectedCategories, selectedModels, selectedTags []string) (state.Filters, error) {
+	var filters state.Filters
 
-	for id, value := range compareMap {
-		if !value {
-			continue
-		} else {
-			carsSelected = append(carsSelected, id)
+	for _, manufacturer := range selectedManufacturers {
+		manufacturerId, err := strconv.Atoi(manufacturer)
+		if err != nil {
+			fmt.Println("Error converting manufacture to int.")
+			return state.Filters{}, err
 		}
+		filters.Manufacturers = append(filters.Manufacturers, manufacturerId)
 	}
 
-	var err error
-	var car models.Car
-	carsDataChannel := make(chan models.Car, len(carsSelected))
-	errChannel := make(chan error, len(carsSelected))
-
-	for _, carId := range carsSelected {
-		go FetchCar(carId, carsDataChannel, errChannel)
-	}
-
-	for i := 0; i < len(carsSelected); i++ {
-		err = <-errChannel
-		car = <-carsDataChannel
+	for _, category := range selectedCategories {
+		categoryId, err := strconv.Atoi(category)
 		if err != nil {
-			return []models.Car{}, err
-		} else {
-			comparedCars = append(comparedCars, car)
+			fmt.Println("Error converting category to int.")
+			return state.Filters{}, err
 		}
+		filters.Categories = append(filters.Categories, categoryId)
 	}
-	return comparedCars, nil
-}
 
-// Modify the global variable ComparisonMap.
-func ModifyComparisonMap(carId int) {
+	filters.Models = append(filters.Models, selectedModels...)
 
-	config.ComparisonMap[carId] = !config.ComparisonMap[carId]
-	count := 0
-	for _, value := range config.ComparisonMap {
-		if value {
-			count++
+	for _, tag := range selectedTags {
+		tag, err := state.TagName(tag)
+		if err != nil {
+			return state.Filters{}, err
 		}
+		filters.Tags = append(filters.Tags, tag)
 	}
-	if count > 1 {
-		config.CompareActive = true
-		count = 0
-	} else {
-		config.CompareActive = false
-	}
-
+	return filters, nil
 }
 
-// Resets the global variable ComparisonMap.
-func ClearComparisonMap() {
-
-	//	Make all carsID false in the ComparisonFilterMap.
-	for key, _ := range config.ComparisonMap {
-		config.ComparisonMap[key] = false
-	}
-	config.CompareActive = false
-
-}
+// Bounds of the range filters: no car is older than the first automobile, and none should go over them.
+const (
+	MinYear       = 1886
+	MaxYear       = 2100
+	MinHorsepower = 1
+	MaxHorsepower = 2000
+)
 
-// Modify the global variable CategoriesFilterMap.
-func ModifyCategoriesFilterMap(selectedItems []string) error {
+// ErrInvalidRange is wrapped by the errors of ParseRange, which tell which bound is wrong and why.
+var ErrInvalidRange = errors.New("invalid range")
 
-	//	For those categories selected, make true their values in the CategoriesFilterMap.
-	//	Zero Categories selected equals to all categories true.
-	if len(selectedItems) > 0 {
-		for _, category := range selectedItems {
-			categoryId, err := strconv.Atoi(category)
-			if err != nil {
-				fmt.Println("Error converting category to int.")
-				return err
-			}
-			config.CategoriesFilterMap[categoryId] = true
-		}
-	} else if len(selectedItems) == 0 {
-		for key, _ := range config.CategoriesFilterMap {
-			config.CategoriesFilterMap[key] = true
-		}
+// Creates a range of the filter form from its minimum and maximum, written as whole numbers
+// between lowest and highest. An empty value has no bound.
+func ParseRange(name, minimum, maximum string, lowest, highest int) (state.Range, error) {
+	var bounds state.Range
+	var err error
+	if bounds.Min, err = parseBound(minimum, lowest, highest); err != nil {
+		return state.Range{}, fmt.Errorf("%w: the minimum %s %v", ErrInvalidRange, name, err)
 	}
-	return nil
-}
-
-// Resets the global variable CategoriesFilterMap.
-func ClearCategoriesFilterMap() {
-
-	//	Make all categories false in the CategoriesFilterMap
-	for key, _ := range config.CategoriesFilterMap {
-		config.CategoriesFilterMap[key] = false
+	if bounds.Max, err = parseBound(maximum, lowest, highest); err != nil {
+		return state.Range{}, fmt.Errorf("%w: the maximum %s %v", ErrInvalidRange, name, err)
+	}
+	if bounds.Min != 0 && bounds.Max != 0 && bounds.Min > bounds.Max {
+		return state.Range{}, fmt.Errorf("%w: the minimum %s cannot be greater than the maximum", ErrInvalidRange, name)
 	}
+	return bounds, nil
 }
 
-// Modify the global variable ManufacturersFilterMap.
-func ModifyManufacturersFilterMap(selectedItems []string) error {
-
-	//	For those manufacturers selected, make true their values in the ManufacturersFilterMap.
-	//	Zero Manufacturers selected equals to all manufacturers true.
-	if len(selectedItems) > 0 {
-		for _, manufacturer := range selectedItems {
-			manufacturerId, err := strconv.Atoi(manufacturer)
-			if err != nil {
-				fmt.Println("Error converting manufacture to int.")
-				return err
-			}
-			config.ManufacturersFilterMap[manufacturerId] = true
-		}
-	} else if len(selectedItems) == 0 {
-		for key, _ := range config.ManufacturersFilterMap {
-			config.ManufacturersFilterMap[key] = true
-		}
+// Reads a bound of a range. 0 is returned for an empty value.
+func parseBound(value string, lowest, highest int) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < lowest || n > highest {
+		return 0, fmt.Errorf("must be a whole number from %d to %d", lowest, highest)
 	}
-	return nil
+	return n, nil
 }
 
-// Resets the global variable ManufacturersFilterMap
-func ClearManufacturersFilterMap() {
+// MaxCompare is the maximum number of cars in one comparison.
+const MaxCompare = state.MaxCompare
 
-	//	Make all Manufacturers false in the ManufacturersFilterMap.
-	for key, _ := range config.ManufacturersFilterMap {
-		config.ManufacturersFilterMap[key] = false
+// Reads the IDs of the cars of a comparison from a list like "1,4,7".
+// Repeated IDs are removed. A comparison needs between 2 and MaxCompare cars.
+func ParseCompareIDs(list string) ([]int, error) {
+	//	Longer lists are rejected before reading them, even if most of their IDs are repeated.
+	tooMany := fmt.Errorf("a comparison can have up to %d cars", MaxCompare)
+	if strings.Count(list, ",") >= MaxCompare*2 {
+		return nil, tooMany
 	}
-}
-
-// Modify the global variable ModelsFilterMap.
-func ModifyModelsFilterMap(selectedItems []string) {
 
-	//	For those models selected, make true their values in the ModelsFilterMap.
-	//	Zero Models selected equals to all models true.
-	if len(selectedItems) > 0 {
-		for _, modelActive := range selectedItems {
-			config.ModelsFilterMap[modelActive] = true
+	var ids []int
+	seen := make(map[int]bool)
+	for _, value := range strings.Split(list, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a car ID", value)
 		}
-	} else if len(selectedItems) == 0 {
-		for key, _ := range config.ModelsFilterMap {
-			config.ModelsFilterMap[key] = true
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+		if len(ids) > MaxCompare {
+			return nil, tooMany
 		}
 	}
-}
 
-// Resets the global variable ModelsFilterMap.
-func ClearModelsFilterMap() {
-
-	//	Make all Models false in the ModelsFilterMap.
-	for key, _ := range config.ModelsFilterMap {
-		config.ModelsFilterMap[key] = false
+	if len(ids) < 2 {
+		return nil, errors.New("a comparison needs at least two cars")
 	}
+	return ids, nil
 }
 
-func ModifyAllFilterMaps(selectedManufacturers, selectedCategories, selectedModels []string) {
-	ModifyManufacturersFilterMap(selectedManufacturers)
-	ModifyCategoriesFilterMap(selectedCategories)
-	ModifyModelsFilterMap(selectedModels)
-}
-
-// Resets all filters: CategoriesFilterMap, ManufacturersFIlterMap and ModelsFilterMap.
-func ClearAllFilters() {
-	ClearManufacturersFilterMap()
-	ClearCategoriesFilterMap()
-	ClearModelsFilterMap()
+// Returns the URL of the comparison of the cars given, like /compare?ids=1,4,7.
+func CompareURL(ids []int) string {
+	return "/compare?ids=" + JoinIDs(ids)
 }
 
-// Generates a map with the last comparison data made by the user.
-func CreateLastCompareMap() {
-	config.LastCompare = make(map[int]bool)
-	for key, value := range config.ComparisonMap {
-		config.LastCompare[key] = value
+// Returns the IDs given as a list like 1,4,7.
+func JoinIDs(ids []int) string {
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = strconv.Itoa(id)
 	}
+	return strings.Join(values, ",")
 }
@@ -1,104 +1,95 @@
 package helpers
 
 import (
-	"cars/pkg/config"
 	"cars/pkg/models"
+	"cars/pkg/reviews"
+	"cars/pkg/search"
+	"cars/pkg/state"
 	"fmt"
-	"log"
+	"html/template"
 	"net/http"
 	"strings"
-	"text/template"
 )
 
-// Takes one variable type models.Car (which has the same structure as the API)
-// and returns a variable type models.Card with all the information needed.
-func CreateSmallCard(car models.Car) (models.Card, error) {
+// Lookup resolves the manufacturer and category of a car by their IDs without calling the API.
+// One Lookup is built per request, so creating N cards does not need N requests.
+type Lookup struct {
+	Manufacturers map[int]models.Manufacturers
+	Categories    map[int]models.Categories
+}
 
-	manufacturerChannel := make(chan models.Manufacturers, 1)
-	manufacturerErrChannel := make(chan error, 1)
-	categoryChannel := make(chan models.Categories, 1)
-	categoryErrChannel := make(chan error, 1)
+// Creates a Lookup indexing the manufacturers and categories given by their IDs.
+func NewLookup(manufacturers []models.Manufacturers, categories []models.Categories) Lookup {
+	lookup := Lookup{
+		Manufacturers: make(map[int]models.Manufacturers, len(manufacturers)),
+		Categories:    make(map[int]models.Categories, len(categories)),
+	}
+	for _, manufacturer := range manufacturers {
+		lookup.Manufacturers[manufacturer.Id] = manufacturer
+	}
+	for _, category := range categories {
+		lookup.Categories[category.Id] = category
+	}
+	return lookup
+}
 
-	go FetchManufacturer(car.ManufacturerID, manufacturerChannel, manufacturerErrChannel)
-	go FetchCategory(car.CategoryID, categoryChannel, categoryErrChannel)
+// Creates a Lookup with the manufacturers and categories currently in the catalog cache.
+func CatalogLookup() Lookup {
+	return NewLookup(Catalog.Manufacturers(), Catalog.Categories())
+}
 
-	manufacturer := <-manufacturerChannel
-	err := <-manufacturerErrChannel
-	if err != nil {
-		fmt.Println("Error fetching data from the API")
-		return models.Card{}, err
-	}
+// Takes one variable type models.Car (which has the same structure as the API)
+// and returns a variable type models.Card with all the information needed.
+// The manufacturer and category names are resolved with the lookup given.
+func CreateSmallCard(car models.Car, lookup Lookup, visitor *state.Store) models.Card {
 
-	category := <-categoryChannel
-	err = <-categoryErrChannel
-	if err != nil {
-		fmt.Println("Error fetching data from the API")
-		return models.Card{}, err
-	}
+	manufacturer := lookup.Manufacturers[car.ManufacturerID]
+	category := lookup.Categories[car.CategoryID]
 
 	var card models.Card
 
 	card.Id = car.Id
 	card.Name = car.Name
 	card.Year = car.Year
-	card.Image = car.Image
+	card.Image = Catalog.ImageURL(car.Image)
 	card.Category = category.Name
 	card.Manufacturer = manufacturer.Name
 
 	//	Liked and Compared are boolean values that will allow the HTML to determine
 	//	the appearance for the correspondent icons.
-	//	We get the values from the maps initialized when running the application.
-	card.Liked = config.FavouritesMap[car.Id]
-	card.Compared = config.ComparisonMap[car.Id]
-
-	return card, nil
+	//	We get the values from the state of the visitor.
+	card.Liked = visitor.IsFavourite(car.Id)
+	card.Compared = visitor.IsCompared(car.Id)
+	card.Collections = CreateCollections(visitor.Collections(), car.Id)
+	card.Tags = visitor.Tags(car.Id)
+	card.Rating, card.Ratings = Reviews.Rating(car.Id)
+
+	return card
 }
 
-func CreateSmallCardsBatch(carsSelected []models.Car) ([]models.Card, error) {
+// Creates a small card for each car, resolving all the manufacturers and categories from one lookup.
+func CreateSmallCardsBatch(carsSelected []models.Car, visitor *state.Store) ([]models.Card, error) {
+	lookup := CatalogLookup()
 	var cards []models.Card
 	for _, car := range carsSelected {
-		card, err := CreateSmallCard(car)
-		if err != nil {
-			fmt.Printf("Error creating small card: %v", err)
-			return []models.Card{}, err
-		}
-		cards = append(cards, card)
+		cards = append(cards, CreateSmallCard(car, lookup, visitor))
 	}
 	return cards, nil
 }
 
 // Takes one variable type models.Car (which has the same structure as the API)
 // and returns a variable type models.ExtendedCard with all the extended information wanted.
-func CreateBigCard(car models.Car) (models.ExtendedCard, error) {
-
-	manufacturerChannel := make(chan models.Manufacturers, 1)
-	errManufacturerChannel := make(chan error, 1)
-	categoryChannel := make(chan models.Categories, 1)
-	errCategoryChannel := make(chan error, 1)
+// The manufacturer and category are resolved with the lookup given.
+func CreateBigCard(car models.Car, lookup Lookup, visitor *state.Store) models.ExtendedCard {
 
-	go FetchCategory(car.CategoryID, categoryChannel, errCategoryChannel)
-	go FetchManufacturer(car.ManufacturerID, manufacturerChannel, errManufacturerChannel)
-
-	category := <-categoryChannel
-	err := <-errCategoryChannel
-	if err != nil {
-		fmt.Println("Error fetching data from the API")
-		return models.ExtendedCard{}, err
-	}
-
-	manufacturer := <-manufacturerChannel
-	err = <-errManufacturerChannel
-
-	if err != nil {
-		fmt.Println("Error fetching data from the API")
-		return models.ExtendedCard{}, err
-	}
+	manufacturer := lookup.Manufacturers[car.ManufacturerID]
+	category := lookup.Categories[car.CategoryID]
 
 	var card models.ExtendedCard
 	card.Id = car.Id
 	card.Name = car.Name
 	card.Year = car.Year
-	card.Image = car.Image
+	card.Image = Catalog.ImageURL(car.Image)
 	card.Category = category.Name
 	card.Manufacturer = manufacturer.Name
 	card.FoundingYear = manufacturer.FoundingYear
@@ -110,140 +101,142 @@ func CreateBigCard(car models.Car) (models.ExtendedCard, error) {
 
 	//	Liked and Compared are boolean values that will allow the HTML to determine
 	//	the appearance for the correspondent icons.
-	//	We get their values from the maps initialized when running the application.
-	card.Liked = config.FavouritesMap[car.Id]
-	card.Compared = config.ComparisonMap[car.Id]
+	//	We get their values from the state of the visitor.
+	card.Liked = visitor.IsFavourite(car.Id)
+	card.Compared = visitor.IsCompared(car.Id)
+	card.Collections = CreateCollections(visitor.Collections(), car.Id)
+	card.Tags = visitor.Tags(car.Id)
 
-	return card, nil
+	return card
 }
 
-func CreateBigCardsBatch(carsSelected []models.Car) ([]models.ExtendedCard, error) {
+// Creates a big card for each car, resolving all the manufacturers and categories from one lookup.
+func CreateBigCardsBatch(carsSelected []models.Car, visitor *state.Store) ([]models.ExtendedCard, error) {
+	lookup := CatalogLookup()
 	var cards []models.ExtendedCard
 	for _, car := range carsSelected {
-		card, err := CreateBigCard(car)
-		if err != nil {
-			log.Printf("Error creating big card: %v", err)
-			return []models.ExtendedCard{}, err
-		}
-		cards = append(cards, card)
+		cards = append(cards, CreateBigCard(car, lookup, visitor))
 	}
 	return cards, nil
 }
 
-// Initializes the global variables FavouritesMap, ComparisonMap, CategoriesFilterMap, ManufacturersFilterMap and ModelsFilterMap.
-func InitVariable(errChannel chan error) {
-	carsDataChannel := make(chan []models.Car, 1)
-	carsErrChannel := make(chan error, 1)
-
-	manufacturersChannel := make(chan []models.Manufacturers, 1)
-	manufacturersErrChannel := make(chan error, 1)
-
-	categoriesChannel := make(chan []models.Categories, 1)
-	categoriesErrChannel := make(chan error, 1)
-
-	var err error
+// Creates the collections to be shown in a page, marking the ones that have the car given.
+// Use 0 as carID when the page is not about a car.
+func CreateCollections(collections []state.Collection, carID int) []models.Collection {
+	var result []models.Collection
+	for _, collection := range collections {
+		result = append(result, models.Collection{
+			Id:     collection.ID,
+			Name:   collection.Name,
+			Size:   len(collection.Cars),
+			HasCar: collection.Contains(carID),
+		})
+	}
+	return result
+}
 
-	go FetchCars(carsDataChannel, carsErrChannel)
-	go FetchManufacturers(manufacturersChannel, manufacturersErrChannel)
-	go FetchCategories(categoriesChannel, categoriesErrChannel)
+// Creates the comparisons of the history to be shown in a page, with the names of their cars.
+// Cars that are no longer in the catalog are left out.
+func CreateHistory(history []state.Comparison) []models.Comparison {
+	var result []models.Comparison
+	for _, comparison := range history {
+		item := models.Comparison{Id: comparison.ID}
+		//	Comparisons kept before the history existed have no date.
+		if !comparison.Created.IsZero() {
+			item.Created = comparison.Created.Format("2 January 2006, 15:04")
+		}
+		for _, car := range FetchCarsByID(comparison.Cars) {
+			item.Cars = append(item.Cars, car.Name)
+		}
+		result = append(result, item)
+	}
+	return result
+}
 
-	carsData := <-carsDataChannel
-	err = <-carsErrChannel
-	if err != nil {
-		fmt.Println("Error fetching data from the API")
-		errChannel <- err
-		return
+// Creates the reviews to be shown in a page, with the name of their car.
+func CreateReviews(list []reviews.Review) []models.Review {
+	var result []models.Review
+	for _, review := range list {
+		result = append(result, CreateReview(review))
 	}
+	return result
+}
 
-	manufacturersData := <-manufacturersChannel
-	err = <-manufacturersErrChannel
-	if err != nil {
-		fmt.Println("Error fetching data from the API")
-		errChannel <- err
-		return
+// Creates one review to be shown in a page. Cars that are no longer in the catalog have no name.
+func CreateReview(review reviews.Review) models.Review {
+	item := models.Review{
+		Id:      review.ID,
+		CarId:   review.CarID,
+		Author:  review.Author,
+		Rating:  review.Rating,
+		Stars:   strings.Repeat("★", review.Rating) + strings.Repeat("☆", 5-review.Rating),
+		Text:    review.Text,
+		Created: review.Created.Format("2 January 2006"),
+		Status:  string(review.Status),
 	}
+	if car, err := Catalog.Car(review.CarID); err == nil {
+		item.CarName = car.Name
+	}
+	return item
+}
 
-	categoriesData := <-categoriesChannel
-	err = <-categoriesErrChannel
-	if err != nil {
+// Fills the catalog cache. The state of each visitor is kept in its own session.
+func InitVariable(errChannel chan error) {
+
+	if err := Catalog.Refresh(); err != nil {
 		fmt.Println("Error fetching data from the API")
 		errChannel <- err
 		return
 	}
 
-	go func() {
-		for i, car := range carsData {
-			config.FavouritesMap[car.Id] = false
-			config.ComparisonMap[car.Id] = false
-			config.ModelsFilterMap[car.Name] = false
-			config.TotalNumCars = i
-		}
-	}()
-
-	go func() {
-		for _, manufacturer := range manufacturersData {
-			config.ManufacturersFilterMap[manufacturer.Id] = false
-		}
-	}()
-
-	go func() {
-		for _, category := range categoriesData {
-			config.CategoriesFilterMap[category.Id] = false
-		}
-	}()
-
 	errChannel <- nil
 	close(errChannel)
 }
 
+// Returns the cars that match the search written in the search bar. Its free words are searched in the
+// index of the catalog, the most relevant first, and its filters, like hp>=300, are applied to the cars found.
+// The error wraps search.ErrInvalidQuery when the search is not valid.
 func SearchQueryCars(query string) ([]models.Car, error) {
-
-	//	Fetch all the cars.
-	carsDataChannel := make(chan []models.Car, 1)
-	errChannel := make(chan error, 1)
-
-	FetchCars(carsDataChannel, errChannel)
-
-	cars := <-carsDataChannel
-	err := <-errChannel
+	parsed, err := search.ParseQuery(query)
 	if err != nil {
-		fmt.Println("Error fetching data from the API")
-		return []models.Car{}, err
+		return nil, err
+	}
+
+	//	The catalog cache keeps an index of the words of every car, built again on every refresh.
+	//	A search of only filters goes through all the cars, in the order of the catalog.
+	var cars []models.Car
+	if text := parsed.Text(); text != "" {
+		cars = Catalog.Search(text)
+	} else {
+		cars = Catalog.Cars()
 	}
 
-	//	Collect all the cars that matches the query
+	lookup := CatalogLookup()
 	var filteredCars []models.Car
-	query = strings.ToLower(query)
 	for _, car := range cars {
-		manufacturerChannel := make(chan models.Manufacturers, len(cars))
-		errManufactureChannel := make(chan error, len(cars))
-		categoryChannel := make(chan models.Categories, len(cars))
-		errCategoryChannel := make(chan error, len(cars))
-
-		go FetchManufacturer(car.ManufacturerID, manufacturerChannel, errManufactureChannel)
-		go FetchCategory(car.CategoryID, categoryChannel, errCategoryChannel)
-
-		manufacturer := <-manufacturerChannel
-		err := <-errManufactureChannel
-		if err != nil {
-			fmt.Println("Error fetching data from the API")
-			return []models.Car{}, err
-		}
-
-		category := <-categoryChannel
-		err = <-errCategoryChannel
-		if err != nil {
-			fmt.Println("Error fetching data from the API")
-			return []models.Car{}, err
-		}
-
-		if strings.Contains(strings.ToLower(car.Name), query) || strings.Contains(strings.ToLower(manufacturer.Name), query) || strings.Contains(strings.ToLower(category.Name), query) {
+		if parsed.Match(car, lookup.Manufacturers[car.ManufacturerID], lookup.Categories[car.CategoryID]) {
 			filteredCars = append(filteredCars, car)
 		}
 	}
 	return filteredCars, nil
 }
 
+// Returns the search with its free words that are in no car replaced by the closest ones that are,
+// to be shown as "did you mean". Returns "" when there is nothing to suggest.
+func SearchSuggestion(query string) string {
+	parsed, err := search.ParseQuery(query)
+	if err != nil {
+		return ""
+	}
+	suggestion := Catalog.Suggest(parsed.Text())
+	if suggestion == "" {
+		return ""
+	}
+	return parsed.WithText(suggestion)
+}
+
+// Renders the template with the name given. html/template escapes the data, since some of it,
+// like the names of the collections, is written by the users.
 func RenderTemplate(w http.ResponseWriter, htmlTemplate []string, name string, data models.DataResponse) {
 
 	tmpl, err := template.ParseFiles(htmlTemplate...)
@@ -44,24 +44,31 @@ type Card struct {
 	Image        string `json:"image"`
 	Liked        bool
 	Compared     bool
+	Collections  []Collection
+	Tags         []string
+	// Rating is the average of the approved reviews of the car, and Ratings how many there are.
+	Rating  float64
+	Ratings int
 }
 
 // ExtendedCard is the struct created for when a car is clicked, or when viewing the favourites or compare pages.
 type ExtendedCard struct {
-	Id           int    `json:"id"`
-	Name         string `json:"name"`
-	Manufacturer string
-	Country      string `json:"country"`
-	FoundingYear int    `json:"foundingYear"`
-	Category     string
-	Year         int    `json:"year"`
-	Engine       string `json:"engine"`
-	Horsepower   int    `json:"horsePower"`
-	Transmission string `json:"transmission"`
-	DriveTrain   string `json:"driveTrain"`
-	Image        string `json:"image"`
-	Liked        bool
-	Compared     bool
+	Id           int          `json:"id"`
+	Name         string       `json:"name"`
+	Manufacturer string       `json:"manufacturer"`
+	Country      string       `json:"country"`
+	FoundingYear int          `json:"foundingYear"`
+	Category     string       `json:"category"`
+	Year         int          `json:"year"`
+	Engine       string       `json:"engine"`
+	Horsepower   int          `json:"horsePower"`
+	Transmission string       `json:"transmission"`
+	DriveTrain   string       `json:"driveTrain"`
+	Image        string       `json:"image"`
+	Liked        bool         `json:"-"`
+	Compared     bool         `json:"-"`
+	Collections  []Collection `json:"-"`
+	Tags         []string     `json:"-"`
 }
 
 // DataResponse is the struct used to send in the response with the HTML.
@@ -73,6 +80,135 @@ type DataResponse struct {
 	Models        []Modelcar
 	NoResults     bool
 	CompareActive bool
+	// Stale is true when the API could not be reached and the data shown is the last good copy.
+	Stale bool
+	// Username is the user logged in, empty for anonymous visitors.
+	Username string
+	// Message is an error or information message shown in forms.
+	Message string
+	Account Account
+	// Collections are the collections of the user, and Collection the one shown in the page.
+	Collections []Collection
+	Collection  Collection
+	// History are the comparisons made by the user, the most recent first.
+	History []Comparison
+	// ShareURL is the link to the page shown, when it can be shared.
+	ShareURL string
+	// CompareIDs are the IDs of the cars of the comparison shown, like 1,4,7, to save it.
+	CompareIDs string
+	Saved      SavedComparison
+	// ShowTransfer shows the links to export and import the favourites.
+	ShowTransfer bool
+	Import       ImportReport
+	// Tags are all the tags of the user, for the filter menu.
+	Tags []string
+	// CarNote is the note and tags of the car shown in the detail page.
+	CarNote CarNote
+	// Reviews are the reviews of the car shown in the detail page.
+	Reviews CarReviews
+	// Moderator is true when the user logged in approves or rejects the reviews.
+	Moderator bool
+	// PendingReviews are the reviews waiting for moderation, the oldest first.
+	PendingReviews []Review
+	// Recent are the cars viewed recently by the visitor, the most recent first.
+	Recent []Card
+	Search SearchForm
+}
+
+// Account is the struct created for the "My account" page.
+type Account struct {
+	Username    string
+	MemberSince string
+	Favourites  int
+	LastCompare int
+}
+
+// Collection is the struct created for the collections of favourite cars.
+// In a card, HasCar tells whether the car of the card is in the collection.
+type Collection struct {
+	Id     int
+	Name   string
+	Size   int
+	HasCar bool
+}
+
+// Comparison is the struct created for each comparison of the history.
+type Comparison struct {
+	Id      int
+	Created string
+	Cars    []string
+}
+
+// SavedComparison is the struct created for a comparison saved with a short code.
+type SavedComparison struct {
+	Code       string
+	Title      string
+	Notes      string
+	Created    string
+	LastViewed string
+}
+
+// ImportReport is the struct created for the result of importing favourites from a file.
+// Unknown are the entries of the file that were left out.
+type ImportReport struct {
+	Done        bool
+	Favourites  int
+	Collections int
+	Unknown     []string
+}
+
+// CarNote is the struct created for the form with the private note and tags of a car.
+// Tags has the tags separated by commas, as they are written in the form.
+type CarNote struct {
+	CarId int
+	Note  string
+	Tags  string
+}
+
+// CarReviews is the struct created for the reviews of a car in its detail page.
+// Mine is the review of the user logged in, shown with its status until it is approved.
+type CarReviews struct {
+	CarId   int
+	Average float64
+	Count   int
+	List    []Review
+	Mine    Review
+	Form    ReviewForm
+}
+
+// Review is the struct created for each review of a car.
+// Stars has the rating drawn as stars, like ★★★★☆.
+type Review struct {
+	Id      int
+	CarId   int
+	CarName string
+	Author  string
+	Rating  int
+	Stars   string
+	Text    string
+	Created string
+	Status  string
+}
+
+// ReviewForm is the struct created for the form to write a review, with the values sent
+// when they are not valid and the message that explains why.
+type ReviewForm struct {
+	Rating  int
+	Text    string
+	Message string
+}
+
+// SearchForm is the struct created for the search bar and the filter menu: the search written, the search suggested
+// as "did you mean" when its words are in no car, the bounds of the year and horsepower ranges as written,
+// and the message shown when they are not valid.
+type SearchForm struct {
+	Query         string
+	Suggestion    string
+	Message       string
+	YearMin       string
+	YearMax       string
+	HorsepowerMin string
+	HorsepowerMax string
 }
 
 type CarSearch struct {
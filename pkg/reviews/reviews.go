@@ -0,0 +1,274 @@
+package reviews
+
+import (
+	"cars/pkg/storage"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Errors returned by the Store.
+var (
+	ErrInvalidRating = errors.New("the rating must be from 1 to 5 stars")
+	ErrInvalidText   = errors.New("the review must have 1 to 2000 characters")
+	ErrNotFound      = errors.New("the review does not exist, or its author wrote it again")
+)
+
+// MaxText is the maximum number of characters of a review.
+const MaxText = 2000
+
+// Status tells whether a review can be seen by everyone.
+type Status string
+
+// New reviews are pending until a moderator approves or rejects them.
+const (
+	Pending  Status = "pending"
+	Approved Status = "approved"
+	Rejected Status = "rejected"
+)
+
+// Review is the rating and the text written by a user about a car.
+type Review struct {
+	ID      int       `json:"id"`
+	CarID   int       `json:"carId"`
+	Author  string    `json:"author"`
+	Rating  int       `json:"rating"`
+	Text    string    `json:"text"`
+	Status  Status    `json:"status"`
+	Created time.Time `json:"created"`
+}
+
+// fileVersion is the version of the format of the reviews file.
+const fileVersion = 1
+
+// reviewsFile is the document written to disk.
+type reviewsFile struct {
+	Version int      `json:"version"`
+	LastID  int      `json:"lastId"`
+	Reviews []Review `json:"reviews"`
+}
+
+// Store keeps the reviews of all the cars. It is safe for concurrent use.
+type Store struct {
+	mu      sync.RWMutex
+	reviews map[int]*Review
+	lastID  int
+
+	//	The same reviews by car and author, and the sum of the approved ratings of each car,
+	//	so the page of a car does not go through every review.
+	byAuthor map[authorKey]*Review
+	ratings  map[int]ratingTotal
+
+	doc *storage.Document
+}
+
+// authorKey identifies the review of an author for a car.
+type authorKey struct {
+	carID  int
+	author string
+}
+
+// ratingTotal is the sum and the number of the approved ratings of a car.
+type ratingTotal struct {
+	sum, count int
+}
+
+// Creates a Store that keeps the reviews only in memory.
+func NewMemoryStore() *Store {
+	return &Store{
+		reviews:  make(map[int]*Review),
+		byAuthor: make(map[authorKey]*Review),
+		ratings:  make(map[int]ratingTotal),
+	}
+}
+
+// Loads the reviews stored in the file at path, if it exists, and starts writing the changes to it.
+func NewFileStore(path string) (*Store, error) {
+	var stored reviewsFile
+	doc, _, err := storage.LoadDocument(path, "reviews", fileVersion, &stored)
+	if err != nil {
+		return nil, err
+	}
+
+	s := NewMemoryStore()
+	s.lastID = stored.LastID
+	for _, review := range stored.Reviews {
+		review := review
+		//	An author has one review per car: the last one written is kept.
+		if old := s.byAuthor[authorKey{review.CarID, review.Author}]; old != nil {
+			if old.ID > review.ID {
+				continue
+			}
+			s.remove(old)
+		}
+		s.add(&review)
+		s.lastID = max(s.lastID, review.ID)
+	}
+
+	s.doc = doc
+	s.doc.Start(s.snapshot)
+	return s, nil
+}
+
+// Saves the review of the author for the car, waiting for moderation.
+// An author has one review per car: writing again replaces it with a new ID, and it has to be approved again.
+// So a moderator who read the review before it was written again cannot approve the new text by its old ID.
+func (s *Store) Submit(carID int, author string, rating int, text string) (Review, error) {
+	if rating < 1 || rating > 5 {
+		return Review{}, ErrInvalidRating
+	}
+	text = strings.TrimSpace(text)
+	if text == "" || utf8.RuneCountInString(text) > MaxText {
+		return Review{}, ErrInvalidText
+	}
+
+	s.mu.Lock()
+	if old := s.byAuthor[authorKey{carID, author}]; old != nil {
+		s.remove(old)
+	}
+	s.lastID++
+	review := &Review{
+		ID:      s.lastID,
+		CarID:   carID,
+		Author:  author,
+		Rating:  rating,
+		Text:    text,
+		Status:  Pending,
+		Created: time.Now(),
+	}
+	s.add(review)
+	submitted := *review
+	s.mu.Unlock()
+
+	s.doc.MarkChanged()
+	return submitted, nil
+}
+
+// Approves or rejects the review.
+func (s *Store) Moderate(id int, approve bool) error {
+	s.mu.Lock()
+	review, ok := s.reviews[id]
+	if ok {
+		s.countRating(review, -1)
+		review.Status = Rejected
+		if approve {
+			review.Status = Approved
+		}
+		s.countRating(review, 1)
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return ErrNotFound
+	}
+	s.doc.MarkChanged()
+	return nil
+}
+
+// Returns the approved reviews of the car, the most recent first.
+func (s *Store) Approved(carID int) []Review {
+	return s.list(func(r *Review) bool {
+		return r.CarID == carID && r.Status == Approved
+	}, true)
+}
+
+// Returns the reviews waiting for moderation, the oldest first.
+func (s *Store) Pending() []Review {
+	return s.list(func(r *Review) bool {
+		return r.Status == Pending
+	}, false)
+}
+
+// Returns the review of the author for the car, whatever its status.
+func (s *Store) ByAuthor(carID int, author string) (Review, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	review := s.byAuthor[authorKey{carID, author}]
+	if review == nil {
+		return Review{}, false
+	}
+	return *review, true
+}
+
+// Returns the average rating of the car and the number of ratings, counting only approved reviews.
+func (s *Store) Rating(carID int) (float64, int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	total := s.ratings[carID]
+	if total.count == 0 {
+		return 0, 0
+	}
+	return float64(total.sum) / float64(total.count), total.count
+}
+
+// Closes the file of the reviews.
+func (s *Store) Close() error {
+	return s.doc.Close()
+}
+
+// Adds the review to the maps. It must be called holding the lock.
+func (s *Store) add(review *Review) {
+	s.reviews[review.ID] = review
+	s.byAuthor[authorKey{review.CarID, review.Author}] = review
+	s.countRating(review, 1)
+}
+
+// Removes the review from the maps. It must be called holding the lock.
+func (s *Store) remove(review *Review) {
+	delete(s.reviews, review.ID)
+	delete(s.byAuthor, authorKey{review.CarID, review.Author})
+	s.countRating(review, -1)
+}
+
+// Adds the rating of the review to the total of its car with sign 1, or takes it out with -1,
+// if the review is approved. It must be called holding the lock.
+func (s *Store) countRating(review *Review, sign int) {
+	if review.Status != Approved {
+		return
+	}
+	total := s.ratings[review.CarID]
+	total.sum += sign * review.Rating
+	total.count += sign
+	if total.count == 0 {
+		delete(s.ratings, review.CarID)
+	} else {
+		s.ratings[review.CarID] = total
+	}
+}
+
+// Returns a copy of the reviews that match, ordered by date.
+func (s *Store) list(match func(*Review) bool, newestFirst bool) []Review {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var result []Review
+	for _, review := range s.reviews {
+		if match(review) {
+			result = append(result, *review)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if newestFirst {
+			return result[i].Created.After(result[j].Created)
+		}
+		return result[i].Created.Before(result[j].Created)
+	})
+	return result
+}
+
+// Returns the document to write with all the reviews, ordered by ID.
+func (s *Store) snapshot() any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored := reviewsFile{Version: fileVersion, LastID: s.lastID, Reviews: []Review{}}
+	for _, review := range s.reviews {
+		stored.Reviews = append(stored.Reviews, *review)
+	}
+	sort.Slice(stored.Reviews, func(i, j int) bool {
+		return stored.Reviews[i].ID < stored.Reviews[j].ID
+	})
+	return stored
+}
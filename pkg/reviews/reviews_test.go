@@ -0,0 +1,79 @@
+package reviews
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func wantRating(t *testing.T, s *Store, carID int, average float64, count int) {
+	t.Helper()
+	if gotAverage, gotCount := s.Rating(carID); gotAverage != average || gotCount != count {
+		t.Errorf("Rating(%d) = %v, %d, want %v, %d", carID, gotAverage, gotCount, average, count)
+	}
+}
+
+func submit(t *testing.T, s *Store, carID int, author string, rating int) Review {
+	t.Helper()
+	review, err := s.Submit(carID, author, rating, "A review.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return review
+}
+
+func TestRatingCountsApprovedReviews(t *testing.T) {
+	s := NewMemoryStore()
+	ann := submit(t, s, 1, "ann", 5)
+	bob := submit(t, s, 1, "bob", 2)
+	submit(t, s, 2, "ann", 1)
+	wantRating(t, s, 1, 0, 0)
+
+	s.Moderate(ann.ID, true)
+	s.Moderate(bob.ID, true)
+	wantRating(t, s, 1, 3.5, 2)
+	wantRating(t, s, 2, 0, 0)
+
+	//	Approving twice counts once, and rejecting takes the rating out.
+	s.Moderate(ann.ID, true)
+	s.Moderate(bob.ID, false)
+	wantRating(t, s, 1, 5, 1)
+
+	//	Writing again replaces the approved review with a pending one.
+	again := submit(t, s, 1, "ann", 3)
+	wantRating(t, s, 1, 0, 0)
+	if review, ok := s.ByAuthor(1, "ann"); !ok || review.ID != again.ID || review.Status != Pending {
+		t.Errorf("ByAuthor(1, ann) = %+v, %v, want the pending review %d", review, ok, again.ID)
+	}
+	if err := s.Moderate(ann.ID, true); !errors.Is(err, ErrNotFound) {
+		t.Errorf("approving the old review: got %v, want ErrNotFound", err)
+	}
+	s.Moderate(again.ID, true)
+	wantRating(t, s, 1, 3, 1)
+}
+
+func TestFileStoreKeepsLastReviewOfAuthor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reviews.json")
+	data := `{"version": 1, "lastId": 3, "reviews": [
+		{"id": 3, "carId": 1, "author": "ann", "rating": 4, "text": "Newer.", "status": "approved"},
+		{"id": 1, "carId": 1, "author": "ann", "rating": 1, "text": "Older.", "status": "approved"},
+		{"id": 2, "carId": 1, "author": "bob", "rating": 2, "text": "Fine.", "status": "approved"}
+	]}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	wantRating(t, s, 1, 3, 2)
+	if review, ok := s.ByAuthor(1, "ann"); !ok || review.ID != 3 {
+		t.Errorf("ByAuthor(1, ann) = %+v, %v, want review 3", review, ok)
+	}
+	if got := len(s.Approved(1)); got != 2 {
+		t.Errorf("got %d approved reviews, want 2", got)
+	}
+}
@@ -2,22 +2,53 @@ package routes
 
 import (
 	"cars/pkg/handlers"
+	"cars/pkg/session"
 	"net/http"
 )
 
-func Routes() *http.ServeMux {
+// ImagesPath is where the car images are served when they do not come from the API.
+const ImagesPath = "/images/"
+
+// Returns the router of the web server. When imagesDir is not empty,
+// the car images found in it are served under ImagesPath.
+// The pages go through the sessions middleware, so every visitor gets its own state.
+func Routes(imagesDir string, sessions *session.Manager) *http.ServeMux {
 	mux := http.NewServeMux()
 
 	fileServer := http.FileServer(http.Dir("./web/static"))
 	mux.Handle("/static/", http.StripPrefix("/static", fileServer))
 
-	mux.HandleFunc("/", handlers.Homepage)
-	mux.HandleFunc("/id", handlers.SelectCar)
-	mux.HandleFunc("/liked-compared", handlers.StatusChange)
-	mux.HandleFunc("/comparePage", handlers.ComparePage)
-	mux.HandleFunc("/lastCompare", handlers.LastCompare)
-	mux.HandleFunc("/favouritePage", handlers.FavouritesPage)
-	mux.HandleFunc("/search", handlers.Filter)
+	if imagesDir != "" {
+		imagesServer := http.FileServer(http.Dir(imagesDir))
+		mux.Handle(ImagesPath, http.StripPrefix(ImagesPath, imagesServer))
+	}
+
+	pages := http.NewServeMux()
+	pages.HandleFunc("/", handlers.Homepage)
+	pages.HandleFunc("/id", handlers.SelectCar)
+	pages.HandleFunc("/notes", handlers.SaveNote)
+	pages.HandleFunc("/reviews", handlers.SaveReview)
+	pages.HandleFunc("/reviews/moderation", handlers.ModerationPage)
+	pages.HandleFunc("/liked-compared", handlers.StatusChange)
+	pages.HandleFunc("/comparePage", handlers.ComparePage)
+	pages.HandleFunc("/compare", handlers.Compare)
+	pages.HandleFunc("/c", handlers.SaveComparison)
+	pages.HandleFunc("/c/{code}", handlers.SavedComparison)
+	pages.HandleFunc("/lastCompare", handlers.LastCompare)
+	pages.HandleFunc("/recent", handlers.RecentPage)
+	pages.HandleFunc("/history", handlers.HistoryPage)
+	pages.HandleFunc("/history/{id}", handlers.ComparisonPage)
+	pages.HandleFunc("/favouritePage", handlers.FavouritesPage)
+	pages.HandleFunc("/favourites/export", handlers.ExportFavourites)
+	pages.HandleFunc("/favourites/import", handlers.ImportFavourites)
+	pages.HandleFunc("/collections", handlers.CollectionsPage)
+	pages.HandleFunc("/collections/{id}", handlers.CollectionPage)
+	pages.HandleFunc("/search", handlers.Filter)
+	pages.HandleFunc("/login", handlers.LoginPage)
+	pages.HandleFunc("/register", handlers.Register)
+	pages.HandleFunc("/logout", handlers.Logout)
+	pages.HandleFunc("/account", handlers.AccountPage)
+	mux.Handle("/", sessions.Middleware(pages))
 
 	return mux
 }
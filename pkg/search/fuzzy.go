@@ -0,0 +1,131 @@
+package search
+
+import "strings"
+
+// fuzzyWeight is the part of the score given to a word that is only close to a word of the query,
+// like "toyota" for "toyta".
+const fuzzyWeight = 0.4
+
+// Returns how many typos are forgiven in a word of the query when searching.
+// Short words and numbers have to be written right: "bmv" could be many things.
+func fuzzyDistance(token string) int {
+	length := len([]rune(token))
+	switch {
+	case isNumber(token) || length < 4:
+		return 0
+	case length < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Returns how many typos are allowed in a word of the query to suggest another word.
+// A suggestion is only a hint, so it allows more typos than the search.
+func suggestDistance(token string) int {
+	length := len([]rune(token))
+	switch {
+	case isNumber(token) || length < 3:
+		return 0
+	case length < 5:
+		return 1
+	case length < 8:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Returns the query with the words that are not in the index replaced by the closest word that is,
+// like "mercedes sedan" for "mercedez sedan". Returns "" when no word can be replaced.
+func (index *Index) Suggest(query string) string {
+	tokens := Tokenize(query)
+	changed := false
+	for i, token := range tokens {
+		if len(index.matches(token)) > 0 {
+			continue
+		}
+		if closest, ok := index.closest(token, suggestDistance(token)); ok {
+			tokens[i] = closest
+			changed = true
+		}
+	}
+	if !changed {
+		return ""
+	}
+	return strings.Join(tokens, " ")
+}
+
+// Returns the words of the index at most maxDistance edits away from the token.
+func (index *Index) similar(token string, maxDistance int) []string {
+	if maxDistance == 0 {
+		return nil
+	}
+	var terms []string
+	for _, term := range index.terms {
+		if editDistance(token, term, maxDistance) <= maxDistance {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// Returns the word of the index closest to the token, at most maxDistance edits away.
+// Between words as close, the one in more cars is chosen.
+func (index *Index) closest(token string, maxDistance int) (string, bool) {
+	if maxDistance == 0 {
+		return "", false
+	}
+	best, bestDistance := "", maxDistance+1
+	for _, term := range index.terms {
+		distance := editDistance(token, term, bestDistance)
+		if distance < bestDistance || (distance == bestDistance && best != "" && len(index.postings[term]) > len(index.postings[best])) {
+			best, bestDistance = term, distance
+		}
+	}
+	return best, best != "" && bestDistance <= maxDistance
+}
+
+// Returns the number of insertions, deletions, substitutions and swaps of two letters next to each other
+// needed to turn a into b. Once it is known to be more than limit, limit+1 is returned.
+func editDistance(a, b string, limit int) int {
+	s, t := []rune(a), []rune(b)
+	if abs(len(s)-len(t)) > limit {
+		return limit + 1
+	}
+
+	//	Three rows of the table are kept: the one being filled and the two before it.
+	prev2 := make([]int, len(t)+1)
+	prev := make([]int, len(t)+1)
+	curr := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return min(prev[len(t)], limit+1)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,55 @@
+package search
+
+import "testing"
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		limit int
+		want  int
+	}{
+		{"toyota", "toyota", 2, 0},
+		{"toyta", "toyota", 2, 1},
+		{"mercedez", "mercedes", 2, 1},
+		{"silverdo", "silverado", 2, 1},
+		// A swap of two letters next to each other is one edit.
+		{"frod", "ford", 2, 1},
+		{"kitten", "sitting", 3, 3},
+		{"", "bmw", 3, 3},
+		// Once over the limit, limit+1 is returned.
+		{"kitten", "sitting", 1, 2},
+		{"ab", "abcdef", 2, 3},
+		{"ñandú", "nandu", 2, 2},
+	}
+	for _, test := range tests {
+		if got := editDistance(test.a, test.b, test.limit); got != test.want {
+			t.Errorf("editDistance(%q, %q, %d) = %d, want %d", test.a, test.b, test.limit, got, test.want)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	index := newTestIndex(t)
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"toyta", "toyota"},
+		{"mercedez", "mercedes"},
+		{"mercedez sedan", "mercedes sedan"},
+		{"silverdo", "silverado"},
+		{"chevrolett silverdo", "chevrolet silverado"},
+		// Words of the catalog are not suggested again.
+		{"toyota", ""},
+		{"ford", ""},
+		// Numbers and short words have to be written right.
+		{"2025", ""},
+		{"xy", ""},
+		{"zzzzzzzz", ""},
+	}
+	for _, test := range tests {
+		if got := index.Suggest(test.query); got != test.want {
+			t.Errorf("Suggest(%q) = %q, want %q", test.query, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package search
+
+import (
+	"cars/pkg/models"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Parameters of the BM25 ranking: K1 limits how much repeating a word raises the score,
+// and B how much longer documents are penalized.
+const (
+	K1 = 1.2
+	B  = 0.75
+)
+
+// prefixWeight is the part of the score given to a word that only starts with a word of the query,
+// so "ferr" finds Ferrari while a whole word still ranks higher.
+// Numbers and single letters only match whole words: "3" should not find every car with 300 hp.
+const (
+	prefixWeight    = 0.5
+	minPrefixLength = 2
+)
+
+// Weights of the fields of a car: a word in the name counts more than a word in the specifications.
+const (
+	nameWeight         = 3
+	manufacturerWeight = 2
+	categoryWeight     = 2
+	countryWeight      = 1
+	specsWeight        = 1
+)
+
+// Index is an inverted index of the cars of the catalog, to search them by words ranked with BM25.
+// It is built once and only read after, so it is safe for concurrent use.
+type Index struct {
+	docs []document
+	// postings has, for each word, the documents with it.
+	postings map[string][]posting
+	// terms are the words of postings in alphabetical order, to find the words with a prefix.
+	terms     []string
+	avgLength float64
+}
+
+// document is a car in the index. Its length is the weighted number of words.
+type document struct {
+	carID  int
+	length float64
+}
+
+// posting is a document with a word, and how many times it has it, weighted by field.
+type posting struct {
+	doc  int
+	freq float64
+}
+
+// Result is a car found by Search and its score. The higher the score, the more relevant the car.
+type Result struct {
+	CarID int
+	Score float64
+}
+
+// Builds the index of the cars given, with the names of their manufacturers, categories and countries,
+// and their specifications.
+func NewIndex(cars []models.Car, manufacturers []models.Manufacturers, categories []models.Categories) *Index {
+	manufacturerByID := make(map[int]models.Manufacturers, len(manufacturers))
+	for _, manufacturer := range manufacturers {
+		manufacturerByID[manufacturer.Id] = manufacturer
+	}
+	categoryByID := make(map[int]models.Categories, len(categories))
+	for _, category := range categories {
+		categoryByID[category.Id] = category
+	}
+
+	index := &Index{postings: make(map[string][]posting)}
+	var totalLength float64
+	for _, car := range cars {
+		freqs := make(map[string]float64)
+		var length float64
+		for _, field := range carFields(car, manufacturerByID[car.ManufacturerID], categoryByID[car.CategoryID]) {
+			for _, token := range Tokenize(field.text) {
+				freqs[token] += field.weight
+				length += field.weight
+			}
+		}
+
+		doc := len(index.docs)
+		index.docs = append(index.docs, document{carID: car.Id, length: length})
+		totalLength += length
+		for token, freq := range freqs {
+			index.postings[token] = append(index.postings[token], posting{doc: doc, freq: freq})
+		}
+	}
+
+	if len(index.docs) > 0 {
+		index.avgLength = totalLength / float64(len(index.docs))
+	}
+	for term := range index.postings {
+		index.terms = append(index.terms, term)
+	}
+	sort.Strings(index.terms)
+	return index
+}
+
+// field is a text of a car that is searched, with the weight of its words.
+type field struct {
+	text   string
+	weight float64
+}
+
+// Returns the texts of the car that are searched: its name, manufacturer, category, country and specifications.
+func carFields(car models.Car, manufacturer models.Manufacturers, category models.Categories) []field {
+	specs := car.Specifications
+	return []field{
+		{car.Name, nameWeight},
+		{manufacturer.Name, manufacturerWeight},
+		{category.Name, categoryWeight},
+		{manufacturer.Country, countryWeight},
+		{strings.Join([]string{
+			strconv.Itoa(car.Year), specs.Engine, strconv.Itoa(specs.Horsepower), specs.Transmission, specs.DriveTrain,
+		}, " "), specsWeight},
+	}
+}
+
+// Returns the cars with any of the words of the query, the most relevant first.
+// A word that is not in any car matches the words with a typo or two of difference, like "toyta" and "toyota".
+// Cars with the same score are ordered by ID.
+func (index *Index) Search(query string) []Result {
+	scores := make(map[int]float64)
+	for _, token := range unique(Tokenize(query)) {
+		matches := index.matches(token)
+		for _, term := range matches {
+			weight := 1.0
+			if term != token {
+				weight = prefixWeight
+			}
+			index.score(term, weight, scores)
+		}
+		if len(matches) == 0 {
+			for _, term := range index.similar(token, fuzzyDistance(token)) {
+				index.score(term, fuzzyWeight, scores)
+			}
+		}
+	}
+
+	results := make([]Result, 0, len(scores))
+	for doc, score := range scores {
+		results = append(results, Result{CarID: index.docs[doc].carID, Score: score})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].CarID < results[j].CarID
+	})
+	return results
+}
+
+// Returns the words of the index that are the token or, when it can be a prefix, start with it.
+func (index *Index) matches(token string) []string {
+	if len([]rune(token)) < minPrefixLength || isNumber(token) {
+		if _, ok := index.postings[token]; ok {
+			return []string{token}
+		}
+		return nil
+	}
+	var terms []string
+	for i := sort.SearchStrings(index.terms, token); i < len(index.terms) && strings.HasPrefix(index.terms[i], token); i++ {
+		terms = append(terms, index.terms[i])
+	}
+	return terms
+}
+
+// Adds the BM25 score of the word to the documents that have it.
+func (index *Index) score(term string, weight float64, scores map[int]float64) {
+	postings := index.postings[term]
+	n := float64(len(index.docs))
+	df := float64(len(postings))
+	idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+	for _, p := range postings {
+		norm := K1 * (1 - B + B*index.docs[p.doc].length/index.avgLength)
+		scores[p.doc] += weight * idf * p.freq * (K1 + 1) / (p.freq + norm)
+	}
+}
+
+// Splits the text into lower case words of letters and numbers. Anything else separates words,
+// so "3.0L V6" becomes "3", "0l" and "v6".
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func isNumber(token string) bool {
+	for _, r := range token {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func unique(tokens []string) []string {
+	seen := make(map[string]bool, len(tokens))
+	var result []string
+	for _, token := range tokens {
+		if !seen[token] {
+			seen[token] = true
+			result = append(result, token)
+		}
+	}
+	return result
+}
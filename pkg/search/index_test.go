@@ -0,0 +1,118 @@
+package search
+
+import (
+	"cars/pkg/models"
+	"encoding/json"
+	"os"
+	"slices"
+	"testing"
+)
+
+// catalog is the catalog of api/data.json, shared by the tests of the package.
+type catalog struct {
+	Manufacturers []models.Manufacturers `json:"manufacturers"`
+	Categories    []models.Categories    `json:"categories"`
+	Cars          []models.Car           `json:"carModels"`
+}
+
+func loadCatalog(t *testing.T) catalog {
+	t.Helper()
+	data, err := os.ReadFile("../../api/data.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c catalog
+	if err := json.Unmarshal(data, &c); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func newTestIndex(t *testing.T) *Index {
+	t.Helper()
+	c := loadCatalog(t)
+	return NewIndex(c.Cars, c.Manufacturers, c.Categories)
+}
+
+func resultIDs(results []Result) []int {
+	ids := make([]int, 0, len(results))
+	for _, result := range results {
+		ids = append(ids, result.CarID)
+	}
+	return ids
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"3.0L V6", []string{"3", "0l", "v6"}},
+		{"Mercedes-Benz E-Class", []string{"mercedes", "benz", "e", "class"}},
+		{"  ", nil},
+	}
+	for _, test := range tests {
+		if got := Tokenize(test.text); !slices.Equal(got, test.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	index := newTestIndex(t)
+	tests := []struct {
+		query string
+		// first is the car expected first, or 0 when nothing is found.
+		first int
+		// without are cars that must not be found.
+		without []int
+	}{
+		{"toyota", 1, []int{2, 3}},
+		{"Silverado", 7, nil},
+		{"bmw", 3, nil},
+		{"mercedes sedan", 5, nil},
+		{"chevrolet truck", 7, []int{1, 9}},
+		{"merc", 5, nil},
+		{"3", 3, []int{1}},
+		{"300", 0, nil},
+		{"b", 0, nil},
+		{"", 0, nil},
+		// Typos.
+		{"toyta", 1, nil},
+		{"mercedez", 5, nil},
+		{"silverdo", 7, nil},
+		{"bmv", 0, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			got := resultIDs(index.Search(test.query))
+			if test.first == 0 {
+				if len(got) != 0 {
+					t.Errorf("Search(%q) = %v, want nothing", test.query, got)
+				}
+				return
+			}
+			if len(got) == 0 || got[0] != test.first {
+				t.Errorf("Search(%q) = %v, want %d first", test.query, got, test.first)
+			}
+			for _, id := range test.without {
+				if slices.Contains(got, id) {
+					t.Errorf("Search(%q) = %v, want no car %d", test.query, got, id)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchRanksWholeWordsFirst(t *testing.T) {
+	index := newTestIndex(t)
+	results := index.Search("toyota corolla")
+	if len(results) != 1 || results[0].CarID != 1 {
+		t.Fatalf("Search(%q) = %v, want only car 1", "toyota corolla", results)
+	}
+	whole := index.Search("ford")[0].Score
+	prefix := index.Search("for")[0].Score
+	if prefix >= whole {
+		t.Errorf("a prefix scores %v, want less than the whole word %v", prefix, whole)
+	}
+}
@@ -0,0 +1,386 @@
+package search
+
+import (
+	"cars/pkg/models"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidQuery is wrapped by the errors of ParseQuery, which tell the part of the query that cannot be read.
+var ErrInvalidQuery = errors.New("invalid search")
+
+// Query is a search written in the search bar, like `hp>=300 drivetrain:AWD year:2020..2023 -manufacturer:Ford`.
+// It has filters on the fields of the cars, and free words that are searched in the index.
+//
+// A filter is a field, an operator and a value:
+//
+//	name, manufacturer, category, country, engine, transmission, drivetrain
+//	    field:value contains the value, field=value is the value. Case does not matter.
+//	year, hp
+//	    field:2020, field:2020..2023, field:2020.., field:..2023, field>2020, field>=2020, field<2020, field<=2020
+//
+// A filter or a word starting with - excludes the cars that match it. Values with spaces go in double quotes,
+// like name:"3 series".
+type Query struct {
+	terms []term
+}
+
+// term is a filter or a free word of a Query.
+type term struct {
+	// raw is the term as it was written, to write the query again.
+	raw     string
+	negated bool
+	// field is "" for a free word.
+	field string
+	exact bool
+	// text is the value of a text field or the free word, in lower case.
+	text string
+	// min and max are the bounds, both included, of a number field.
+	min, max int
+}
+
+// Names of the fields of a car that can be filtered.
+const (
+	fieldName         = "name"
+	fieldManufacturer = "manufacturer"
+	fieldCategory     = "category"
+	fieldCountry      = "country"
+	fieldEngine       = "engine"
+	fieldTransmission = "transmission"
+	fieldDriveTrain   = "drivetrain"
+	fieldYear         = "year"
+	fieldHorsepower   = "hp"
+)
+
+// fieldNames has the names of the fields and their aliases, in lower case.
+var fieldNames = map[string]string{
+	"name":         fieldName,
+	"model":        fieldName,
+	"manufacturer": fieldManufacturer,
+	"make":         fieldManufacturer,
+	"brand":        fieldManufacturer,
+	"category":     fieldCategory,
+	"country":      fieldCountry,
+	"engine":       fieldEngine,
+	"transmission": fieldTransmission,
+	"drivetrain":   fieldDriveTrain,
+	"drive":        fieldDriveTrain,
+	"year":         fieldYear,
+	"hp":           fieldHorsepower,
+	"horsepower":   fieldHorsepower,
+}
+
+// fieldList is the list of fields shown when a field is not known.
+const fieldList = "name, manufacturer, category, country, engine, transmission, drivetrain, year or hp"
+
+// driveTrains are the usual abbreviations of the drive trains.
+var driveTrains = map[string]string{
+	"awd": "all-wheel drive",
+	"4wd": "four-wheel drive",
+	"4x4": "four-wheel drive",
+	"fwd": "front-wheel drive",
+	"rwd": "rear-wheel drive",
+}
+
+// operators are tried in this order, so >= is not read as >.
+var operators = []string{">=", "<=", ">", "<", ":", "="}
+
+// Reads a search written in the search bar. Words that are not filters are kept as free words.
+func ParseQuery(text string) (Query, error) {
+	chunks, err := splitQuery(text)
+	if err != nil {
+		return Query{}, err
+	}
+	var query Query
+	for _, chunk := range chunks {
+		t, err := parseTerm(chunk)
+		if err != nil {
+			return Query{}, err
+		}
+		query.terms = append(query.terms, t)
+	}
+	return query, nil
+}
+
+// chunk is a term of the query, as written and without its quotes.
+type chunk struct {
+	raw, text string
+}
+
+// Splits the query by spaces, except the spaces in double quotes.
+func splitQuery(text string) ([]chunk, error) {
+	var chunks []chunk
+	var raw, clean strings.Builder
+	quoted := false
+	flush := func() {
+		if raw.Len() > 0 {
+			chunks = append(chunks, chunk{raw: raw.String(), text: clean.String()})
+		}
+		raw.Reset()
+		clean.Reset()
+	}
+	for _, r := range text {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			raw.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			flush()
+		default:
+			raw.WriteRune(r)
+			clean.WriteRune(r)
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("%w: a double quote is not closed", ErrInvalidQuery)
+	}
+	flush()
+	return chunks, nil
+}
+
+// Reads a filter, like year:2020..2023, or a free word.
+func parseTerm(c chunk) (term, error) {
+	t := term{raw: c.raw}
+	text := c.text
+	if strings.HasPrefix(text, "-") {
+		t.negated = true
+		text = text[1:]
+		if text == "" {
+			return term{}, fmt.Errorf("%w: %q must be followed by a word or a filter, like -manufacturer:Ford", ErrInvalidQuery, c.raw)
+		}
+	}
+
+	//	A filter starts with a name of letters followed by an operator. Anything else is a free word.
+	end := strings.IndexFunc(text, func(r rune) bool { return !unicode.IsLetter(r) })
+	operator := ""
+	if end > 0 {
+		for _, op := range operators {
+			if strings.HasPrefix(text[end:], op) {
+				operator = op
+				break
+			}
+		}
+	}
+	if operator == "" {
+		t.text = strings.ToLower(text)
+		return t, nil
+	}
+
+	name := strings.ToLower(text[:end])
+	value := strings.TrimSpace(text[end+len(operator):])
+	field, ok := fieldNames[name]
+	if !ok {
+		return term{}, fmt.Errorf("%w: %q is not a field, use %s", ErrInvalidQuery, text[:end], fieldList)
+	}
+	if value == "" {
+		return term{}, fmt.Errorf("%w: %q needs a value, like %s", ErrInvalidQuery, c.raw, examples[field])
+	}
+	t.field = field
+
+	if field == fieldYear || field == fieldHorsepower {
+		t.min, t.max = math.MinInt, math.MaxInt
+		err := parseBounds(&t, operator, value)
+		if err != nil {
+			return term{}, fmt.Errorf("%w: %q %v, like %s", ErrInvalidQuery, c.raw, err, examples[field])
+		}
+		return t, nil
+	}
+
+	if operator != ":" && operator != "=" {
+		return term{}, fmt.Errorf("%w: %q cannot use %s, %s is text: use %s:value or %s=value", ErrInvalidQuery, c.raw, operator, name, name, name)
+	}
+	t.exact = operator == "="
+	t.text = strings.ToLower(value)
+	if field == fieldDriveTrain {
+		if full, ok := driveTrains[t.text]; ok {
+			t.text = full
+		}
+	}
+	return t, nil
+}
+
+// Reads the bounds of a number field from the operator and the value, like >= 300 or : 2020..2023.
+func parseBounds(t *term, operator, value string) error {
+	if operator == ":" || operator == "=" {
+		from, to, isRange := strings.Cut(value, "..")
+		if !isRange {
+			n, err := parseNumber(value)
+			t.min, t.max = n, n
+			return err
+		}
+		if from == "" && to == "" {
+			return errors.New("needs at least one bound")
+		}
+		var err error
+		if from != "" {
+			if t.min, err = parseNumber(from); err != nil {
+				return err
+			}
+		}
+		if to != "" {
+			if t.max, err = parseNumber(to); err != nil {
+				return err
+			}
+		}
+		if t.min > t.max {
+			return errors.New("has its first bound greater than the last one")
+		}
+		return nil
+	}
+
+	n, err := parseNumber(value)
+	if err != nil {
+		return err
+	}
+	switch operator {
+	case ">":
+		if n == math.MaxInt {
+			//	No number is greater, so the range is left empty instead of overflowing.
+			t.min, t.max = math.MaxInt, math.MinInt
+			return nil
+		}
+		t.min = n + 1
+	case ">=":
+		t.min = n
+	case "<":
+		if n == math.MinInt {
+			t.min, t.max = math.MaxInt, math.MinInt
+			return nil
+		}
+		t.max = n - 1
+	case "<=":
+		t.max = n
+	}
+	return nil
+}
+
+func parseNumber(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("needs a whole number instead of %q", value)
+	}
+	return n, nil
+}
+
+// examples are filters on each field, shown in the error messages.
+var examples = map[string]string{
+	fieldName:         "name:civic",
+	fieldManufacturer: "manufacturer:Ford",
+	fieldCategory:     "category:SUV",
+	fieldCountry:      "country:Japan",
+	fieldEngine:       "engine:V6",
+	fieldTransmission: "transmission:manual",
+	fieldDriveTrain:   "drivetrain:AWD",
+	fieldYear:         "year:2020..2023",
+	fieldHorsepower:   "hp>=300",
+}
+
+// Returns the free words of the query that are not excluded, to be searched in the index.
+func (q Query) Text() string {
+	var words []string
+	for _, t := range q.terms {
+		if t.field == "" && !t.negated {
+			words = append(words, t.text)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// Returns the query with its free words replaced by the text given, in the place of the first of them,
+// keeping the filters and the excluded words. It is used to write again a query with a suggestion.
+func (q Query) WithText(text string) string {
+	var parts []string
+	replaced := false
+	for _, t := range q.terms {
+		if t.field != "" || t.negated {
+			parts = append(parts, t.raw)
+			continue
+		}
+		if !replaced && text != "" {
+			parts = append(parts, text)
+		}
+		replaced = true
+	}
+	if !replaced && text != "" {
+		parts = append(parts, text)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Reports whether the car passes the filters of the query and has none of the words excluded.
+// The free words that are not excluded are not checked: they are searched in the index.
+func (q Query) Match(car models.Car, manufacturer models.Manufacturers, category models.Categories) bool {
+	var tokens []string
+	for _, t := range q.terms {
+		var matched bool
+		switch t.field {
+		case "":
+			if !t.negated {
+				continue
+			}
+			if tokens == nil {
+				for _, f := range carFields(car, manufacturer, category) {
+					tokens = append(tokens, Tokenize(f.text)...)
+				}
+			}
+			matched = containsAll(tokens, Tokenize(t.text))
+		case fieldYear:
+			matched = t.min <= car.Year && car.Year <= t.max
+		case fieldHorsepower:
+			matched = t.min <= car.Specifications.Horsepower && car.Specifications.Horsepower <= t.max
+		default:
+			matched = t.matchText(fieldText(t.field, car, manufacturer, category))
+		}
+		if matched == t.negated {
+			return false
+		}
+	}
+	return true
+}
+
+// Reports whether the value of a text field matches the term, ignoring case.
+func (t term) matchText(value string) bool {
+	value = strings.ToLower(value)
+	if t.exact {
+		return value == t.text
+	}
+	return strings.Contains(value, t.text)
+}
+
+// Returns the value of a text field of the car.
+func fieldText(field string, car models.Car, manufacturer models.Manufacturers, category models.Categories) string {
+	switch field {
+	case fieldName:
+		return car.Name
+	case fieldManufacturer:
+		return manufacturer.Name
+	case fieldCategory:
+		return category.Name
+	case fieldCountry:
+		return manufacturer.Country
+	case fieldEngine:
+		return car.Specifications.Engine
+	case fieldTransmission:
+		return car.Specifications.Transmission
+	case fieldDriveTrain:
+		return car.Specifications.DriveTrain
+	}
+	return ""
+}
+
+func containsAll(tokens, words []string) bool {
+	if len(words) == 0 {
+		return false
+	}
+	for _, word := range words {
+		if !slices.Contains(tokens, word) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,127 @@
+package search
+
+import (
+	"cars/pkg/models"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// Returns the IDs of the cars of the catalog that pass the filters of the query.
+func matching(t *testing.T, c catalog, query Query) []int {
+	t.Helper()
+	manufacturers := make(map[int]models.Manufacturers)
+	for _, manufacturer := range c.Manufacturers {
+		manufacturers[manufacturer.Id] = manufacturer
+	}
+	categories := make(map[int]models.Categories)
+	for _, category := range c.Categories {
+		categories[category.Id] = category
+	}
+	ids := []int{}
+	for _, car := range c.Cars {
+		if query.Match(car, manufacturers[car.ManufacturerID], categories[car.CategoryID]) {
+			ids = append(ids, car.Id)
+		}
+	}
+	return ids
+}
+
+func TestParseQueryMatch(t *testing.T) {
+	c := loadCatalog(t)
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"hp>=300", []int{6, 7}},
+		{"hp>=300 drivetrain:AWD year:2020..2023 category:SUV -manufacturer:Ford", []int{}},
+		{"drivetrain:AWD", []int{4}},
+		{"drive:rwd", []int{3, 5, 6, 7}},
+		{"drivetrain=front-wheel drive", []int{}},
+		{`drivetrain="front-wheel drive"`, []int{1, 2, 8, 9, 10}},
+		{"year:2020..2023", []int{1, 3, 5, 7, 9}},
+		{"year:2024..", []int{2, 4, 6, 8, 10}},
+		{"year:..2023", []int{1, 3, 5, 7, 9}},
+		{"year=2024 hp<190", []int{2, 10}},
+		{"hp>255", []int{6, 7, 9}},
+		{"hp<=139", []int{1}},
+		{"horsepower:255", []int{3, 5}},
+		{"manufacturer=ford", []int{6}},
+		{"manufacturer:for", []int{6}},
+		{"manufacturer=for", []int{}},
+		{"make:BMW", []int{3}},
+		{"category:truck -manufacturer:ford", []int{7}},
+		{`name:"3 series"`, []int{3}},
+		{"country:japan engine:v6", []int{9}},
+		{"transmission:manual", []int{2}},
+		{"-japan", []int{3, 4, 5, 6, 7, 8}},
+		{"-sedan -truck", []int{9}},
+		// Free words are searched in the index, so they do not filter here.
+		{"toyota", []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		// The bounds at the limits of int do not overflow.
+		{"hp>9223372036854775807", []int{}},
+		{"-hp>9223372036854775807", []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		{"year<-9223372036854775808", []int{}},
+		{"hp<=9223372036854775807", []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			query, err := ParseQuery(test.query)
+			if err != nil {
+				t.Fatalf("ParseQuery(%q): %v", test.query, err)
+			}
+			if got := matching(t, c, query); !slices.Equal(got, test.want) {
+				t.Errorf("ParseQuery(%q) matches %v, want %v", test.query, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	queries := []string{
+		`name:"3 series`,
+		"-",
+		"colour:red",
+		"hp:",
+		"hp>",
+		"hp:abc",
+		"hp>=3x",
+		"year:..",
+		"year:2023..2020",
+		"year:2020..abc",
+		"name>3",
+		"country<japan",
+		"hp>9223372036854775808",
+	}
+	for _, text := range queries {
+		_, err := ParseQuery(text)
+		if !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("ParseQuery(%q) error = %v, want ErrInvalidQuery", text, err)
+		}
+	}
+}
+
+func TestQueryText(t *testing.T) {
+	tests := []struct {
+		query    string
+		text     string
+		withText string
+	}{
+		{"bmw hp>200 sedan", "bmw sedan", "toyota hp>200"},
+		{"hp>200 toyta", "toyta", "hp>200 toyota"},
+		{"hp>200 -ford", "", "hp>200 -ford toyota"},
+		{`Toyta name:"3 series"`, "toyta", `toyota name:"3 series"`},
+	}
+	for _, test := range tests {
+		query, err := ParseQuery(test.query)
+		if err != nil {
+			t.Fatalf("ParseQuery(%q): %v", test.query, err)
+		}
+		if got := query.Text(); got != test.text {
+			t.Errorf("ParseQuery(%q).Text() = %q, want %q", test.query, got, test.text)
+		}
+		if got := query.WithText("toyota"); got != test.withText {
+			t.Errorf("ParseQuery(%q).WithText(%q) = %q, want %q", test.query, "toyota", got, test.withText)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package session
+
+import (
+	"cars/pkg/state"
+	"cars/pkg/storage"
+	"time"
+)
+
+// fileVersion is the version of the format of the sessions file.
+// Files written by older versions are migrated when they are loaded.
+const fileVersion = 1
+
+// sessionsFile is the document written to disk by FileStore.
+type sessionsFile struct {
+	Version  int                      `json:"version"`
+	Sessions map[string]storedSession `json:"sessions"`
+}
+
+// storedSession is a session as written to disk. Sessions of a user account
+// only keep the username: their state is stored with the account.
+type storedSession struct {
+	LastSeen time.Time      `json:"lastSeen"`
+	Username string         `json:"username,omitempty"`
+	State    state.Snapshot `json:"state"`
+}
+
+// UserStates returns the state of a user account, or nil if the account does not exist.
+type UserStates func(username string) *state.Store
+
+// FileStore keeps the sessions in memory and persists them to a JSON file, so the
+// favourites, comparisons and preferences of the visitors survive a restart.
+// Only the sessions with something in their state are written.
+type FileStore struct {
+	*MemoryStore
+	doc *storage.Document
+}
+
+// Loads the sessions stored in the file at path, if it exists, and starts writing the changes to it.
+// userStates links the stored sessions of logged in users to their accounts.
+func NewFileStore(path string, userStates UserStates) (*FileStore, error) {
+	var stored sessionsFile
+	doc, found, err := storage.LoadDocument(path, "sessions", fileVersion, &stored)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &FileStore{MemoryStore: NewMemoryStore(), doc: doc}
+	if found {
+		migrate(&stored)
+		for id, storedSession := range stored.Sessions {
+			session := &Session{ID: id, Username: storedSession.Username}
+			if session.Username == "" {
+				session.State = state.FromSnapshot(storedSession.State)
+			} else if session.State = userStates(session.Username); session.State == nil {
+				//	The account does not exist anymore.
+				continue
+			}
+			session.lastSeen.Store(storedSession.LastSeen.UnixNano())
+			s.MemoryStore.Save(session)
+		}
+	}
+
+	s.doc.Start(s.snapshot)
+	for _, session := range s.MemoryStore.all() {
+		s.watch(session)
+	}
+	return s, nil
+}
+
+// Upgrades a file written by an older version to the current format.
+// Files newer than fileVersion are refused when they are loaded.
+func migrate(stored *sessionsFile) {
+	if stored.Version == 0 {
+		//	Version 0 is an empty or hand written file without version.
+		stored.Version = fileVersion
+	}
+	if stored.Sessions == nil {
+		stored.Sessions = make(map[string]storedSession)
+	}
+}
+
+// Adds the session and persists its state from now on.
+func (s *FileStore) Save(session *Session) {
+	s.MemoryStore.Save(session)
+	s.watch(session)
+	s.doc.MarkChanged()
+}
+
+func (s *FileStore) Delete(id string) {
+	s.MemoryStore.Delete(id)
+	s.doc.MarkChanged()
+}
+
+func (s *FileStore) DeleteExpired(before time.Time) {
+	s.MemoryStore.DeleteExpired(before)
+	s.doc.MarkChanged()
+}
+
+// Closes the file of the sessions.
+func (s *FileStore) Close() error {
+	return s.doc.Close()
+}
+
+// Writes the file every time the state of an anonymous session changes.
+// The state of a user session belongs to the account, which is persisted with it.
+func (s *FileStore) watch(session *Session) {
+	if session.Username == "" {
+		session.State.OnChange(s.doc.MarkChanged)
+	}
+}
+
+// Returns the document to write with all the sessions worth keeping.
+func (s *FileStore) snapshot() any {
+	stored := sessionsFile{
+		Version:  fileVersion,
+		Sessions: make(map[string]storedSession),
+	}
+	for _, session := range s.MemoryStore.all() {
+		storedSession := storedSession{LastSeen: session.LastSeen(), Username: session.Username}
+		if session.Username == "" {
+			storedSession.State = session.State.Snapshot()
+			//	Visitors that did nothing are not worth keeping.
+			if storedSession.State.Empty() {
+				continue
+			}
+		}
+		stored.Sessions[session.ID] = storedSession
+	}
+	return stored
+}
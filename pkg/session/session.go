@@ -0,0 +1,296 @@
+package session
+
+import (
+	"cars/pkg/state"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Session links one visitor (one browser) to its state: the cars liked, the cars selected
+// to be compared, the last comparison, the URL to go back to and the filters applied.
+// When the visitor is logged in, Username is set and State is the state of the account.
+// ID, Username and State never change: logging in or out replaces the session.
+type Session struct {
+	ID       string
+	Username string
+	State    *state.Store
+
+	//	Unix time in nanoseconds of the last visit. It is atomic because requests
+	//	of the same visitor and the cleanup of expired sessions run concurrently.
+	lastSeen atomic.Int64
+}
+
+// Creates a Session with the ID given and an empty state.
+func New(id string) *Session {
+	session := &Session{
+		ID:    id,
+		State: state.New(),
+	}
+	session.Touch()
+	return session
+}
+
+// Records a visit now.
+func (s *Session) Touch() {
+	s.lastSeen.Store(time.Now().UnixNano())
+}
+
+// Returns the time of the last visit.
+func (s *Session) LastSeen() time.Time {
+	return time.Unix(0, s.lastSeen.Load())
+}
+
+// Store keeps the sessions by their ID.
+type Store interface {
+	// Returns the session with the ID given, and false if it does not exist.
+	Get(id string) (*Session, bool)
+	// Adds the session, or replaces the one with the same ID.
+	Save(session *Session)
+	// Removes the session with the ID given.
+	Delete(id string)
+	// Removes the sessions not seen since the time given.
+	DeleteExpired(before time.Time)
+}
+
+// MemoryStore keeps the sessions in memory. They are lost when the server stops.
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]*Session
+}
+
+// Creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: make(map[string]*Session)}
+}
+
+func (s *MemoryStore) Get(id string) (*Session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	return session, ok
+}
+
+func (s *MemoryStore) Save(session *Session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[session.ID] = session
+}
+
+func (s *MemoryStore) Delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+}
+
+// Returns all the sessions.
+func (s *MemoryStore) all() []*Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sessions := make([]*Session, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+func (s *MemoryStore) DeleteExpired(before time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, session := range s.sessions {
+		if session.LastSeen().Before(before) {
+			delete(s.sessions, id)
+		}
+	}
+}
+
+// CookieName is the name of the cookie that carries the session ID.
+const CookieName = "cars_session"
+
+// Manager links every request to the session of its visitor through a cookie.
+type Manager struct {
+	Store Store
+	// MaxAge is how long a session lives without visits.
+	MaxAge time.Duration
+}
+
+// Creates a Manager keeping the sessions in the store given.
+func NewManager(store Store, maxAge time.Duration) *Manager {
+	return &Manager{Store: store, MaxAge: maxAge}
+}
+
+type contextKey struct{}
+
+type managerKey struct{}
+
+type pendingKey struct{}
+
+// Middleware loads the session of the visitor and makes it available to the next handler through FromRequest.
+// Visitors without a session get a new one, but it is only stored, and its cookie sent, once something
+// is kept in its state: crawlers and visitors that only look around do not fill the store.
+func (m *Manager) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session := m.load(r)
+		if session == nil {
+			pending := &pendingWriter{ResponseWriter: w, manager: m, session: New(newID())}
+			//	The handlers change the state before they write the response, or when they write nothing.
+			defer pending.commit()
+			w, session = pending, pending.session
+			r = r.WithContext(context.WithValue(r.Context(), pendingKey{}, pending))
+		} else {
+			session.Touch()
+			//	The cookie is sent on every response so that its expiration is extended.
+			m.setCookie(w, session)
+		}
+
+		ctx := context.WithValue(r.Context(), contextKey{}, session)
+		ctx = context.WithValue(ctx, managerKey{}, m)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// pendingWriter holds the new session of a request until the response is written,
+// and stores it then if its state is not empty.
+type pendingWriter struct {
+	http.ResponseWriter
+	manager   *Manager
+	session   *Session
+	committed bool
+}
+
+// Stores the session and sends its cookie if something was kept in its state. It only acts once,
+// before the headers are written.
+func (w *pendingWriter) commit() {
+	if w.committed {
+		return
+	}
+	w.committed = true
+	if !w.session.State.Empty() {
+		w.manager.Store.Save(w.session)
+		w.manager.setCookie(w.ResponseWriter, w.session)
+	}
+}
+
+func (w *pendingWriter) WriteHeader(statusCode int) {
+	w.commit()
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *pendingWriter) Write(b []byte) (int, error) {
+	w.commit()
+	return w.ResponseWriter.Write(b)
+}
+
+// Returns the ResponseWriter wrapped, for http.ResponseController.
+func (w *pendingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Sends the cookie that links the browser to the session.
+func (m *Manager) setCookie(w http.ResponseWriter, session *Session) {
+	sendCookie(w, session.ID, int(m.MaxAge.Seconds()))
+}
+
+// Sends the session cookie with the value and max age given. A negative max age removes it.
+// A session cookie added before to the response, like the one extending the session, is replaced.
+func sendCookie(w http.ResponseWriter, value string, maxAge int) {
+	header := w.Header()
+	header["Set-Cookie"] = slices.DeleteFunc(header["Set-Cookie"], func(cookie string) bool {
+		return strings.HasPrefix(cookie, CookieName+"=")
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     CookieName,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// Replaces the session of the request with a new one, with a new ID, and sends its cookie.
+// A new ID is used on every login, so an ID known before cannot be used after.
+func (m *Manager) replace(w http.ResponseWriter, r *http.Request, session *Session) *Session {
+	m.Store.Delete(FromRequest(r).ID)
+	if pending, ok := r.Context().Value(pendingKey{}).(*pendingWriter); ok {
+		//	The new session of the request is not needed anymore.
+		pending.committed = true
+	}
+	m.Store.Save(session)
+	m.setCookie(w, session)
+	return session
+}
+
+// Starts a session for the user logged in, sharing the state of the account given.
+// The request must have gone through the Middleware.
+func Login(w http.ResponseWriter, r *http.Request, username string, account *state.Store) *Session {
+	session := New(newID())
+	session.Username = username
+	session.State = account
+	return managerFromRequest(r).replace(w, r, session)
+}
+
+// Ends the session of the request and removes its cookie. The next request starts a new anonymous
+// session, so the ID known before cannot be used after.
+// The request must have gone through the Middleware.
+func Logout(w http.ResponseWriter, r *http.Request) {
+	managerFromRequest(r).Store.Delete(FromRequest(r).ID)
+	sendCookie(w, "", -1)
+}
+
+func managerFromRequest(r *http.Request) *Manager {
+	return r.Context().Value(managerKey{}).(*Manager)
+}
+
+// Returns the session of the cookie in the request, or nil if there is none or it expired.
+func (m *Manager) load(r *http.Request) *Session {
+	cookie, err := r.Cookie(CookieName)
+	if err != nil || cookie.Value == "" {
+		return nil
+	}
+	session, ok := m.Store.Get(cookie.Value)
+	if !ok || time.Since(session.LastSeen()) > m.MaxAge {
+		return nil
+	}
+	return session
+}
+
+// Removes the expired sessions every interval until the context is cancelled. It does not block.
+func (m *Manager) StartCleanup(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.Store.DeleteExpired(time.Now().Add(-m.MaxAge))
+			}
+		}
+	}()
+}
+
+// Returns the session attached to the request by the Middleware.
+// Requests that did not go through the Middleware get an empty session that is not stored.
+func FromRequest(r *http.Request) *Session {
+	if session, ok := r.Context().Value(contextKey{}).(*Session); ok {
+		return session
+	}
+	return New("")
+}
+
+// Returns a new random session ID.
+func newID() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,162 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Run with go test -race: many requests of the same visitor reach its session at the same time.
+func TestMiddlewareConcurrentRequests(t *testing.T) {
+	const workers = 16
+	const iterations = 50
+
+	manager := NewManager(NewMemoryStore(), time.Hour)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/like", func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.URL.Query().Get("id"))
+		if err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		state := FromRequest(r).State
+		state.ToggleFavourite(id)
+		state.ToggleCompare(id)
+		state.SnapshotLastCompare()
+		state.Snapshot()
+	})
+	server := httptest.NewServer(manager.Middleware(mux))
+	defer server.Close()
+
+	//	The first request creates the session, and every other one shares its cookie.
+	resp, err := http.Get(server.URL + "/like?id=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	var cookie *http.Cookie
+	for _, c := range resp.Cookies() {
+		if c.Name == CookieName {
+			cookie = c
+		}
+	}
+	if cookie == nil {
+		t.Fatal("no session cookie in the first response")
+	}
+
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			//	Every car is liked an odd number of times, so it ends up liked.
+			for range 2*iterations + 1 {
+				req, err := http.NewRequest(http.MethodGet, server.URL+"/like?id="+strconv.Itoa(w+1), nil)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				req.AddCookie(cookie)
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					t.Errorf("got status %d, want %d", resp.StatusCode, http.StatusOK)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	session, ok := manager.Store.Get(cookie.Value)
+	if !ok {
+		t.Fatal("the session of the cookie is not in the store")
+	}
+	favourites := session.State.Favourites()
+	if len(favourites) != workers+1 {
+		t.Errorf("got favourites %v, want the cars 0 to %d", favourites, workers)
+	}
+	if session.State.CompareActive() {
+		t.Errorf("got cars selected %v, want none", session.State.Compared())
+	}
+}
+
+func sessionCookie(resp *http.Response) *http.Cookie {
+	for _, c := range resp.Cookies() {
+		if c.Name == CookieName {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestMiddlewareStoresSessionsWithState(t *testing.T) {
+	store := NewMemoryStore()
+	manager := NewManager(store, time.Hour)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/look", func(w http.ResponseWriter, r *http.Request) {
+		//	Clearing an empty state keeps nothing.
+		FromRequest(r).State.ClearRecent()
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/like", func(w http.ResponseWriter, r *http.Request) {
+		FromRequest(r).State.ToggleFavourite(1)
+		http.Redirect(w, r, "/look", http.StatusSeeOther)
+	})
+	mux.HandleFunc("/silent", func(w http.ResponseWriter, r *http.Request) {
+		FromRequest(r).State.ToggleFavourite(2)
+	})
+	handler := manager.Middleware(mux)
+
+	for _, path := range []string{"/look", "/missing"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if cookie := sessionCookie(rec.Result()); cookie != nil {
+			t.Errorf("%s: got a session cookie for a visitor with an empty state", path)
+		}
+	}
+	if sessions := store.all(); len(sessions) != 0 {
+		t.Fatalf("got %d sessions stored, want none", len(sessions))
+	}
+
+	for _, path := range []string{"/like", "/silent"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		cookie := sessionCookie(rec.Result())
+		if cookie == nil {
+			t.Fatalf("%s: no session cookie after changing the state", path)
+		}
+		if _, ok := store.Get(cookie.Value); !ok {
+			t.Errorf("%s: the session of the cookie is not in the store", path)
+		}
+	}
+}
+
+func TestLogoutRemovesSession(t *testing.T) {
+	store := NewMemoryStore()
+	manager := NewManager(store, time.Hour)
+	session := New(newID())
+	store.Save(session)
+
+	handler := manager.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Logout(w, r)
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/logout", nil)
+	req.AddCookie(&http.Cookie{Name: CookieName, Value: session.ID})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if _, ok := store.Get(session.ID); ok {
+		t.Error("the session is still in the store after logging out")
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != CookieName || cookies[0].MaxAge >= 0 {
+		t.Errorf("got cookies %v, want only the session cookie removed", cookies)
+	}
+}
@@ -0,0 +1,174 @@
+package shares
+
+import (
+	"cars/pkg/storage"
+	"crypto/rand"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Errors returned by Save.
+var (
+	ErrInvalidTitle = errors.New("the title must have 1 to 100 characters")
+	ErrNotesTooLong = errors.New("the notes can have up to 1000 characters")
+)
+
+// Limits of the text saved with a comparison.
+const (
+	MaxTitle = 100
+	MaxNotes = 1000
+)
+
+// CodeLength is the number of characters of a code, like Ab3xK.
+const CodeLength = 5
+
+// codeAlphabet has the characters of the codes: letters and numbers, so they are safe in a URL.
+const codeAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// Comparison is a comparison saved to be shared with its code.
+type Comparison struct {
+	Code       string    `json:"code"`
+	Title      string    `json:"title"`
+	Notes      string    `json:"notes,omitempty"`
+	Cars       []int     `json:"cars"`
+	Created    time.Time `json:"created"`
+	LastViewed time.Time `json:"lastViewed"`
+}
+
+// fileVersion is the version of the format of the saved comparisons file.
+const fileVersion = 1
+
+// sharesFile is the document written to disk.
+type sharesFile struct {
+	Version     int          `json:"version"`
+	Comparisons []Comparison `json:"comparisons"`
+}
+
+// Store keeps the saved comparisons by their code. It is safe for concurrent use.
+type Store struct {
+	mu          sync.RWMutex
+	comparisons map[string]Comparison
+
+	doc *storage.Document
+}
+
+// Creates a Store that keeps the comparisons only in memory.
+func NewMemoryStore() *Store {
+	return &Store{comparisons: make(map[string]Comparison)}
+}
+
+// Loads the comparisons stored in the file at path, if it exists, and starts writing the changes to it.
+func NewFileStore(path string) (*Store, error) {
+	var stored sharesFile
+	doc, _, err := storage.LoadDocument(path, "saved comparisons", fileVersion, &stored)
+	if err != nil {
+		return nil, err
+	}
+
+	s := NewMemoryStore()
+	for _, comparison := range stored.Comparisons {
+		s.comparisons[comparison.Code] = comparison
+	}
+	s.doc = doc
+	s.doc.Start(s.snapshot)
+	return s, nil
+}
+
+// Saves a comparison of the cars given and returns it with its new code.
+func (s *Store) Save(title, notes string, cars []int) (Comparison, error) {
+	title = strings.TrimSpace(title)
+	if title == "" || utf8.RuneCountInString(title) > MaxTitle {
+		return Comparison{}, ErrInvalidTitle
+	}
+	notes = strings.TrimSpace(notes)
+	if utf8.RuneCountInString(notes) > MaxNotes {
+		return Comparison{}, ErrNotesTooLong
+	}
+
+	now := time.Now()
+	comparison := Comparison{
+		Title:      title,
+		Notes:      notes,
+		Cars:       slices.Clone(cars),
+		Created:    now,
+		LastViewed: now,
+	}
+
+	s.mu.Lock()
+	//	Codes are random, so a new one is tried in the rare case it is already used.
+	for {
+		comparison.Code = newCode()
+		if _, ok := s.comparisons[comparison.Code]; !ok {
+			break
+		}
+	}
+	s.comparisons[comparison.Code] = comparison
+	s.mu.Unlock()
+
+	s.doc.MarkChanged()
+	return cloneComparison(comparison), nil
+}
+
+// Returns the comparison with the code given, as it was before this view, and records that it was viewed now.
+func (s *Store) View(code string) (Comparison, bool) {
+	s.mu.Lock()
+	comparison, ok := s.comparisons[code]
+	if ok {
+		viewed := comparison
+		viewed.LastViewed = time.Now()
+		s.comparisons[code] = viewed
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return Comparison{}, false
+	}
+	s.doc.MarkChanged()
+	return cloneComparison(comparison), true
+}
+
+// Closes the file of the saved comparisons.
+func (s *Store) Close() error {
+	return s.doc.Close()
+}
+
+// Returns the document to write with all the comparisons, the oldest first.
+func (s *Store) snapshot() any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored := sharesFile{Version: fileVersion, Comparisons: []Comparison{}}
+	for _, comparison := range s.comparisons {
+		stored.Comparisons = append(stored.Comparisons, cloneComparison(comparison))
+	}
+	sort.Slice(stored.Comparisons, func(i, j int) bool {
+		return stored.Comparisons[i].Created.Before(stored.Comparisons[j].Created)
+	})
+	return stored
+}
+
+// Returns a random code of CodeLength characters.
+func newCode() string {
+	//	Bytes over the last multiple of the alphabet length are skipped,
+	//	so every character is equally likely.
+	limit := 256 - 256%len(codeAlphabet)
+	code := make([]byte, 0, CodeLength)
+	random := make([]byte, 1)
+	for len(code) < CodeLength {
+		rand.Read(random)
+		if int(random[0]) < limit {
+			code = append(code, codeAlphabet[int(random[0])%len(codeAlphabet)])
+		}
+	}
+	return string(code)
+}
+
+func cloneComparison(comparison Comparison) Comparison {
+	comparison.Cars = slices.Clone(comparison.Cars)
+	return comparison
+}
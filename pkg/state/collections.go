@@ -0,0 +1,185 @@
+package state
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Errors returned by the methods of the collections.
+var (
+	ErrCollectionNotFound    = errors.New("the collection does not exist")
+	ErrInvalidCollectionName = errors.New("the name of a collection must have 1 to 50 characters")
+	ErrCollectionExists      = errors.New("there is already a collection with that name")
+)
+
+// MaxCollectionName is the maximum number of characters of the name of a collection.
+const MaxCollectionName = 50
+
+// Collection is a named list of cars, like "Family shortlist" or "Weekend cars".
+type Collection struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Cars are the IDs of the cars in the order they were added.
+	Cars []int `json:"cars,omitempty"`
+}
+
+// Reports whether the car is in the collection.
+func (c Collection) Contains(carID int) bool {
+	return slices.Contains(c.Cars, carID)
+}
+
+// Creates an empty collection with the name given.
+func (s *Store) CreateCollection(name string) (Collection, error) {
+	name, err := CollectionName(name)
+	if err != nil {
+		return Collection{}, err
+	}
+
+	var created Collection
+	s.update(func() {
+		if s.findCollectionByName(name) != -1 {
+			err = ErrCollectionExists
+			return
+		}
+		created = Collection{ID: s.nextCollectionID(), Name: name}
+		s.collections = append(s.collections, created)
+	})
+	return created, err
+}
+
+// Changes the name of the collection.
+func (s *Store) RenameCollection(id int, name string) error {
+	name, err := CollectionName(name)
+	if err != nil {
+		return err
+	}
+
+	s.update(func() {
+		i := s.findCollection(id)
+		if i == -1 {
+			err = ErrCollectionNotFound
+			return
+		}
+		if other := s.findCollectionByName(name); other != -1 && other != i {
+			err = ErrCollectionExists
+			return
+		}
+		s.collections[i].Name = name
+	})
+	return err
+}
+
+// Deletes the collection. The cars stay in the favourites.
+func (s *Store) DeleteCollection(id int) error {
+	var err error
+	s.update(func() {
+		i := s.findCollection(id)
+		if i == -1 {
+			err = ErrCollectionNotFound
+			return
+		}
+		s.collections = slices.Delete(s.collections, i, i+1)
+	})
+	return err
+}
+
+// Adds the car to the collection if it was not in it, and the other way around.
+// Returns whether the car is in the collection now.
+func (s *Store) ToggleInCollection(id, carID int) (bool, error) {
+	var added bool
+	var err error
+	s.update(func() {
+		i := s.findCollection(id)
+		if i == -1 {
+			err = ErrCollectionNotFound
+			return
+		}
+		collection := &s.collections[i]
+		if j := slices.Index(collection.Cars, carID); j != -1 {
+			collection.Cars = slices.Delete(collection.Cars, j, j+1)
+			return
+		}
+		collection.Cars = append(collection.Cars, carID)
+		added = true
+	})
+	return added, err
+}
+
+// Replaces the cars liked and the collections with the ones given, like when a backup is restored.
+// The collections get new IDs.
+func (s *Store) RestoreFavourites(favourites []int, collections []Collection) {
+	s.update(func() {
+		clear(s.favourites)
+		for _, carID := range favourites {
+			s.favourites[carID] = true
+		}
+		s.collections = nil
+		for _, collection := range collections {
+			collection = cloneCollection(collection)
+			collection.ID = s.nextCollectionID()
+			s.collections = append(s.collections, collection)
+		}
+	})
+}
+
+// Returns a copy of the collection with the ID given.
+func (s *Store) Collection(id int) (Collection, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i := s.findCollection(id)
+	if i == -1 {
+		return Collection{}, false
+	}
+	return cloneCollection(s.collections[i]), true
+}
+
+// Returns a copy of all the collections, in the order they were created.
+func (s *Store) Collections() []Collection {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return cloneCollections(s.collections)
+}
+
+// Returns the position of the collection with the ID given, or -1.
+func (s *Store) findCollection(id int) int {
+	return slices.IndexFunc(s.collections, func(c Collection) bool {
+		return c.ID == id
+	})
+}
+
+// Returns the position of the collection with the name given, ignoring case, or -1.
+func (s *Store) findCollectionByName(name string) int {
+	return slices.IndexFunc(s.collections, func(c Collection) bool {
+		return strings.EqualFold(c.Name, name)
+	})
+}
+
+// Returns an ID that no collection has. IDs are not reused, so old links never point to another collection.
+func (s *Store) nextCollectionID() int {
+	s.lastCollectionID++
+	return s.lastCollectionID
+}
+
+// Trims the name of a collection and checks its length.
+func CollectionName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > MaxCollectionName {
+		return "", ErrInvalidCollectionName
+	}
+	return name, nil
+}
+
+func cloneCollection(collection Collection) Collection {
+	collection.Cars = slices.Clone(collection.Cars)
+	return collection
+}
+
+func cloneCollections(collections []Collection) []Collection {
+	var clones []Collection
+	for _, collection := range collections {
+		clones = append(clones, cloneCollection(collection))
+	}
+	return clones
+}
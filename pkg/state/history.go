@@ -0,0 +1,89 @@
+package state
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// ErrComparisonNotFound is returned when a comparison is not in the history.
+var ErrComparisonNotFound = errors.New("the comparison is not in the history")
+
+// MaxHistory is the number of comparisons kept in the history. The oldest ones are dropped first.
+const MaxHistory = 50
+
+// Comparison is a comparison made by the visitor, kept in the history.
+type Comparison struct {
+	ID      int       `json:"id"`
+	Created time.Time `json:"created"`
+	Cars    []int     `json:"cars"`
+}
+
+// Returns a copy of the comparisons of the history, the most recent first.
+func (s *Store) History() []Comparison {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	history := cloneHistory(s.history)
+	slices.Reverse(history)
+	return history
+}
+
+// Returns a copy of the comparison of the history with the ID given.
+func (s *Store) Comparison(id int) (Comparison, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i := s.findComparison(id)
+	if i == -1 {
+		return Comparison{}, false
+	}
+	return cloneComparison(s.history[i]), true
+}
+
+// Removes the comparison from the history.
+func (s *Store) DeleteComparison(id int) error {
+	var err error
+	s.update(func() {
+		i := s.findComparison(id)
+		if i == -1 {
+			err = ErrComparisonNotFound
+			return
+		}
+		s.history = slices.Delete(s.history, i, i+1)
+	})
+	return err
+}
+
+// Adds a comparison of the cars given to the history, dropping the oldest ones over MaxHistory.
+// It must be called holding the lock.
+func (s *Store) addComparison(created time.Time, cars []int) {
+	s.lastComparisonID++
+	s.history = append(s.history, Comparison{ID: s.lastComparisonID, Created: created, Cars: slices.Clone(cars)})
+	s.trimHistory()
+}
+
+// Drops the oldest comparisons over MaxHistory. It must be called holding the lock.
+func (s *Store) trimHistory() {
+	if len(s.history) > MaxHistory {
+		s.history = slices.Delete(s.history, 0, len(s.history)-MaxHistory)
+	}
+}
+
+// Returns the position of the comparison with the ID given, or -1.
+func (s *Store) findComparison(id int) int {
+	return slices.IndexFunc(s.history, func(c Comparison) bool {
+		return c.ID == id
+	})
+}
+
+func cloneComparison(comparison Comparison) Comparison {
+	comparison.Cars = slices.Clone(comparison.Cars)
+	return comparison
+}
+
+func cloneHistory(history []Comparison) []Comparison {
+	var clones []Comparison
+	for _, comparison := range history {
+		clones = append(clones, cloneComparison(comparison))
+	}
+	return clones
+}
@@ -0,0 +1,134 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Errors returned by SetNote.
+var (
+	ErrNoteTooLong = errors.New("the note can have up to 2000 characters")
+	ErrInvalidTag  = errors.New("a tag must have 1 to 30 letters, numbers, spaces, dashes or underscores")
+	ErrTooManyTags = errors.New("a car can have up to 20 tags")
+)
+
+// Limits of the notes and tags of a car.
+const (
+	MaxNote = 2000
+	MaxTag  = 30
+	MaxTags = 20
+)
+
+// tagMarks are the characters, besides letters and numbers, allowed in a tag.
+const tagMarks = " -_"
+
+// Replaces the private note and the tags of the car. An empty note and no tags remove them.
+// Tags are kept in lower case, and repeated tags are removed. Nothing is changed when
+// the note or a tag is not valid.
+func (s *Store) SetNote(carID int, note string, tags []string) error {
+	note = strings.TrimSpace(note)
+	if utf8.RuneCountInString(note) > MaxNote {
+		return ErrNoteTooLong
+	}
+
+	var clean []string
+	for _, tag := range tags {
+		tag, err := TagName(tag)
+		if err != nil {
+			return fmt.Errorf("%w: %q", err, tag)
+		}
+		if !slices.Contains(clean, tag) {
+			clean = append(clean, tag)
+		}
+	}
+	if len(clean) > MaxTags {
+		return ErrTooManyTags
+	}
+	sort.Strings(clean)
+
+	s.update(func() {
+		if note == "" {
+			delete(s.notes, carID)
+		} else {
+			s.notes[carID] = note
+		}
+		if len(clean) == 0 {
+			delete(s.tags, carID)
+		} else {
+			s.tags[carID] = clean
+		}
+	})
+	return nil
+}
+
+// Returns the private note of the car, or "" if it has none.
+func (s *Store) Note(carID int) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.notes[carID]
+}
+
+// Returns the tags of the car, in alphabetical order.
+func (s *Store) Tags(carID int) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.tags[carID])
+}
+
+// Returns all the tags used in any car, in alphabetical order.
+func (s *Store) AllTags() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var all []string
+	for _, tags := range s.tags {
+		for _, tag := range tags {
+			if !slices.Contains(all, tag) {
+				all = append(all, tag)
+			}
+		}
+	}
+	sort.Strings(all)
+	return all
+}
+
+// Reports whether the car has all the tags given. A car has all the tags of an empty list.
+func (s *Store) HasTags(carID int, tags []string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, tag := range tags {
+		if !slices.Contains(s.tags[carID], tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// Trims a tag, puts it in lower case and checks it.
+func TagName(tag string) (string, error) {
+	tag = strings.ToLower(strings.Join(strings.Fields(tag), " "))
+	if tag == "" || utf8.RuneCountInString(tag) > MaxTag {
+		return tag, ErrInvalidTag
+	}
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune(tagMarks, r) {
+			return tag, ErrInvalidTag
+		}
+	}
+	return tag, nil
+}
+
+func cloneTags(tags map[int][]string) map[int][]string {
+	if len(tags) == 0 {
+		return nil
+	}
+	clone := make(map[int][]string, len(tags))
+	for carID, carTags := range tags {
+		clone[carID] = slices.Clone(carTags)
+	}
+	return clone
+}
@@ -0,0 +1,37 @@
+package state
+
+import "slices"
+
+// MaxRecent is the number of cars kept in the list of recently viewed cars. The oldest ones are dropped first.
+const MaxRecent = 20
+
+// Puts the car first in the list of recently viewed cars. A car viewed again is moved to the front.
+func (s *Store) AddRecent(carID int) {
+	s.update(func() {
+		s.recent = addRecent(s.recent, carID)
+	})
+}
+
+// Returns the IDs of the cars viewed, the most recent first.
+func (s *Store) Recent() []int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.recent)
+}
+
+// Empties the list of recently viewed cars.
+func (s *Store) ClearRecent() {
+	s.update(func() {
+		s.recent = nil
+	})
+}
+
+// Returns the list with the car first, without repeating it and without going over MaxRecent.
+func addRecent(recent []int, carID int) []int {
+	recent = slices.DeleteFunc(recent, func(id int) bool { return id == carID })
+	recent = slices.Insert(recent, 0, carID)
+	if len(recent) > MaxRecent {
+		recent = recent[:MaxRecent]
+	}
+	return recent
+}
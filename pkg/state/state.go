@@ -0,0 +1,406 @@
+package state
+
+import (
+	"cars/pkg/models"
+	"errors"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Filters are the manufacturers, categories, models and tags selected in the filter menu,
+// and the ranges of years and horsepower. An empty list or range lets every car through.
+type Filters struct {
+	Manufacturers []int    `json:"manufacturers,omitempty"`
+	Categories    []int    `json:"categories,omitempty"`
+	Models        []string `json:"models,omitempty"`
+	// Tags are private to each visitor, so Match does not check them: see Store.HasTags.
+	Tags       []string `json:"tags,omitempty"`
+	Year       Range    `json:"year"`
+	Horsepower Range    `json:"horsepower"`
+}
+
+// Range are the minimum and the maximum of a number, both included. 0 is no bound.
+type Range struct {
+	Min int `json:"min,omitempty"`
+	Max int `json:"max,omitempty"`
+}
+
+// Reports whether the number is in the range.
+func (r Range) Contains(n int) bool {
+	return (r.Min == 0 || n >= r.Min) && (r.Max == 0 || n <= r.Max)
+}
+
+// Reports whether the car passes the filters of the catalog fields.
+func (f Filters) Match(car models.Car) bool {
+	return (len(f.Manufacturers) == 0 || slices.Contains(f.Manufacturers, car.ManufacturerID)) &&
+		(len(f.Categories) == 0 || slices.Contains(f.Categories, car.CategoryID)) &&
+		(len(f.Models) == 0 || slices.Contains(f.Models, car.Name)) &&
+		f.Year.Contains(car.Year) && f.Horsepower.Contains(car.Specifications.Horsepower)
+}
+
+// Reports whether no filter is applied.
+func (f Filters) Empty() bool {
+	return len(f.Manufacturers) == 0 && len(f.Categories) == 0 && len(f.Models) == 0 && len(f.Tags) == 0 &&
+		f.Year == Range{} && f.Horsepower == Range{}
+}
+
+// Store holds the state of one visitor: the cars liked, the collections of cars, the private notes
+// and tags of each car, the cars selected to be compared, the history of comparisons,
+// the cars viewed recently and the filters applied.
+// It is safe for concurrent use: the state is only reached through its methods,
+// which never return the internal maps or slices.
+type Store struct {
+	mu         sync.RWMutex
+	favourites map[int]bool
+	compare    map[int]bool
+	filters    Filters
+
+	collections      []Collection
+	lastCollectionID int
+
+	notes map[int]string
+	tags  map[int][]string
+
+	//	The comparisons made, the oldest first.
+	history          []Comparison
+	lastComparisonID int
+
+	//	The cars viewed, the most recent first.
+	recent []int
+
+	//	Called after every change that has to be persisted.
+	onChange func()
+}
+
+// Creates an empty Store.
+func New() *Store {
+	return &Store{
+		favourites: make(map[int]bool),
+		compare:    make(map[int]bool),
+		notes:      make(map[int]string),
+		tags:       make(map[int][]string),
+	}
+}
+
+// Likes the car if it was not liked, and the other way around. Returns whether the car is liked now.
+func (s *Store) ToggleFavourite(carID int) bool {
+	var liked bool
+	s.update(func() {
+		liked = toggle(s.favourites, carID)
+	})
+	return liked
+}
+
+// Reports whether the car is liked.
+func (s *Store) IsFavourite(carID int) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.favourites[carID]
+}
+
+// Returns the IDs of the cars liked, in ascending order.
+func (s *Store) Favourites() []int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sortedKeys(s.favourites)
+}
+
+// MaxCompare is the maximum number of cars in one comparison.
+const MaxCompare = 10
+
+// ErrCompareFull is returned by ToggleCompare when MaxCompare cars are already selected.
+var ErrCompareFull = errors.New("a comparison can have up to 10 cars")
+
+// Selects the car to be compared if it was not selected, and the other way around.
+// Returns whether the car is selected now. A car that is selected can always be unselected.
+func (s *Store) ToggleCompare(carID int) (bool, error) {
+	var selected bool
+	var err error
+	s.update(func() {
+		if !s.compare[carID] && len(s.compare) >= MaxCompare {
+			err = ErrCompareFull
+			return
+		}
+		selected = toggle(s.compare, carID)
+	})
+	return selected, err
+}
+
+// Reports whether the car is selected to be compared.
+func (s *Store) IsCompared(carID int) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.compare[carID]
+}
+
+// Returns the IDs of the cars selected to be compared, in ascending order.
+func (s *Store) Compared() []int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sortedKeys(s.compare)
+}
+
+// Reports whether there are enough cars selected (at least two) to make a comparison.
+func (s *Store) CompareActive() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.compare) > 1
+}
+
+// Unselects all the cars selected to be compared.
+func (s *Store) ClearCompare() {
+	s.update(func() {
+		clear(s.compare)
+	})
+}
+
+// Saves the cars selected to be compared as a new comparison of the history, unselects them,
+// and returns their IDs. It is done in one step, so no selection is lost between both.
+// Less than two cars are not a comparison, so they are not saved.
+func (s *Store) SnapshotLastCompare() []int {
+	var snapshot []int
+	s.update(func() {
+		snapshot = sortedKeys(s.compare)
+		clear(s.compare)
+		if len(snapshot) > 1 {
+			s.addComparison(time.Now(), snapshot)
+		}
+	})
+	return snapshot
+}
+
+// Returns the IDs of the cars of the last comparison.
+func (s *Store) LastCompare() []int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.history) == 0 {
+		return nil
+	}
+	return slices.Clone(s.history[len(s.history)-1].Cars)
+}
+
+// Returns a copy of the filters applied.
+func (s *Store) Filters() Filters {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return cloneFilters(s.filters)
+}
+
+// Replaces the filters applied.
+func (s *Store) SetFilters(filters Filters) {
+	s.update(func() {
+		s.filters = cloneFilters(filters)
+	})
+}
+
+// Snapshot is a copy of the part of the state that is kept across restarts.
+type Snapshot struct {
+	Favourites []int   `json:"favourites,omitempty"`
+	Compare    []int   `json:"compare,omitempty"`
+	Filters    Filters `json:"filters"`
+
+	Collections      []Collection `json:"collections,omitempty"`
+	LastCollectionID int          `json:"lastCollectionId,omitempty"`
+
+	History          []Comparison `json:"history,omitempty"`
+	LastComparisonID int          `json:"lastComparisonId,omitempty"`
+
+	Notes map[int]string   `json:"notes,omitempty"`
+	Tags  map[int][]string `json:"tags,omitempty"`
+
+	// Recent are the cars viewed, the most recent first.
+	Recent []int `json:"recent,omitempty"`
+
+	// LastCompare is only read from the files saved before the history was kept.
+	LastCompare []int `json:"lastCompare,omitempty"`
+}
+
+// Reports whether there is nothing worth keeping in the snapshot.
+func (s Snapshot) Empty() bool {
+	return len(s.Favourites) == 0 && len(s.Compare) == 0 && len(s.LastCompare) == 0 &&
+		s.Filters.Empty() && len(s.Collections) == 0 && len(s.History) == 0 &&
+		len(s.Notes) == 0 && len(s.Tags) == 0 && len(s.Recent) == 0
+}
+
+// Reports whether nothing is stored in the state, like in a new one.
+func (s *Store) Empty() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.favourites) == 0 && len(s.compare) == 0 && s.filters.Empty() && len(s.collections) == 0 &&
+		len(s.history) == 0 && len(s.notes) == 0 && len(s.tags) == 0 && len(s.recent) == 0
+}
+
+// Returns a copy of the state to be persisted.
+func (s *Store) Snapshot() Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return Snapshot{
+		Favourites: sortedKeys(s.favourites),
+		Compare:    sortedKeys(s.compare),
+		Filters:    cloneFilters(s.filters),
+
+		Collections:      cloneCollections(s.collections),
+		LastCollectionID: s.lastCollectionID,
+
+		History:          cloneHistory(s.history),
+		LastComparisonID: s.lastComparisonID,
+
+		Notes: maps.Clone(s.notes),
+		Tags:  cloneTags(s.tags),
+
+		Recent: slices.Clone(s.recent),
+	}
+}
+
+// Creates a Store with the state of a snapshot.
+func FromSnapshot(snapshot Snapshot) *Store {
+	s := New()
+	for _, carID := range snapshot.Favourites {
+		s.favourites[carID] = true
+	}
+	for _, carID := range snapshot.Compare {
+		s.compare[carID] = true
+	}
+	s.filters = cloneFilters(snapshot.Filters)
+	s.collections = cloneCollections(snapshot.Collections)
+	s.lastCollectionID = snapshot.LastCollectionID
+	for _, collection := range s.collections {
+		s.lastCollectionID = max(s.lastCollectionID, collection.ID)
+	}
+	s.history = cloneHistory(snapshot.History)
+	s.lastComparisonID = snapshot.LastComparisonID
+	for _, comparison := range s.history {
+		s.lastComparisonID = max(s.lastComparisonID, comparison.ID)
+	}
+	for carID, note := range snapshot.Notes {
+		s.notes[carID] = note
+	}
+	for carID, tags := range snapshot.Tags {
+		s.tags[carID] = slices.Clone(tags)
+	}
+	s.recent = slices.Clone(snapshot.Recent)
+	//	The last comparison of an older file becomes the first one of the history, without date.
+	if len(s.history) == 0 && len(snapshot.LastCompare) > 0 {
+		s.addComparison(time.Time{}, snapshot.LastCompare)
+	}
+	return s
+}
+
+// Adds the state of a snapshot to this one: the cars liked, the cars selected to be compared, the tags,
+// the histories of comparisons and the cars viewed are joined, while the filters and the note of each car
+// are only taken when this state has none.
+// Collections with the same name are joined too, and the others are added with a new ID.
+func (s *Store) Merge(other Snapshot) {
+	s.update(func() {
+		for _, carID := range other.Favourites {
+			s.favourites[carID] = true
+		}
+		//	The joined selection keeps at most MaxCompare cars, the ones of this state first.
+		for _, carID := range other.Compare {
+			if len(s.compare) < MaxCompare {
+				s.compare[carID] = true
+			}
+		}
+		if len(other.History) > 0 {
+			for _, comparison := range other.History {
+				s.lastComparisonID++
+				comparison = cloneComparison(comparison)
+				comparison.ID = s.lastComparisonID
+				s.history = append(s.history, comparison)
+			}
+			slices.SortStableFunc(s.history, func(a, b Comparison) int {
+				return a.Created.Compare(b.Created)
+			})
+			s.trimHistory()
+		}
+		if s.filters.Empty() {
+			s.filters = cloneFilters(other.Filters)
+		}
+		for carID, note := range other.Notes {
+			if s.notes[carID] == "" {
+				s.notes[carID] = note
+			}
+		}
+		for carID, tags := range other.Tags {
+			for _, tag := range tags {
+				if !slices.Contains(s.tags[carID], tag) {
+					s.tags[carID] = append(s.tags[carID], tag)
+				}
+			}
+			sort.Strings(s.tags[carID])
+		}
+		//	The cars of the snapshot were viewed last, like before logging in, so they go first.
+		for i := len(other.Recent) - 1; i >= 0; i-- {
+			s.recent = addRecent(s.recent, other.Recent[i])
+		}
+		for _, collection := range other.Collections {
+			i := s.findCollectionByName(collection.Name)
+			if i == -1 {
+				collection = cloneCollection(collection)
+				collection.ID = s.nextCollectionID()
+				s.collections = append(s.collections, collection)
+				continue
+			}
+			for _, carID := range collection.Cars {
+				if !s.collections[i].Contains(carID) {
+					s.collections[i].Cars = append(s.collections[i].Cars, carID)
+				}
+			}
+		}
+	})
+}
+
+// Sets the function called after every change that has to be persisted.
+// It is called without holding the lock, so it can read the Store.
+func (s *Store) OnChange(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChange = fn
+}
+
+// Runs the change holding the lock, and then notifies it.
+func (s *Store) update(change func()) {
+	s.mu.Lock()
+	change()
+	onChange := s.onChange
+	s.mu.Unlock()
+
+	if onChange != nil {
+		onChange()
+	}
+}
+
+// Flips the value of the key. Keys are removed instead of being set to false,
+// so the length of the map is the number of keys set. Returns the new value.
+func toggle(m map[int]bool, key int) bool {
+	if m[key] {
+		delete(m, key)
+		return false
+	}
+	m[key] = true
+	return true
+}
+
+// Returns the keys of the map in ascending order.
+func sortedKeys(m map[int]bool) []int {
+	keys := make([]int, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+func cloneFilters(filters Filters) Filters {
+	return Filters{
+		Manufacturers: slices.Clone(filters.Manufacturers),
+		Categories:    slices.Clone(filters.Categories),
+		Models:        slices.Clone(filters.Models),
+		Tags:          slices.Clone(filters.Tags),
+		Year:          filters.Year,
+		Horsepower:    filters.Horsepower,
+	}
+}
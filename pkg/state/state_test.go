@@ -0,0 +1,130 @@
+package state
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// Run with go test -race: the store is shared by every request of a visitor.
+func TestStoreConcurrentUse(t *testing.T) {
+	const workers = 8
+	const iterations = 200
+
+	s := New()
+	var changes atomic.Int64
+	s.OnChange(func() {
+		//	The function is called without the lock, so it can read the store.
+		s.Snapshot()
+		changes.Add(1)
+	})
+
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			own := w + 1
+			merged := 100 + w
+			for range iterations {
+				//	An even number of toggles leaves the car as it was.
+				s.ToggleFavourite(own)
+				s.ToggleFavourite(own)
+				s.ToggleCompare(own)
+				s.IsCompared(own)
+				s.SnapshotLastCompare()
+				s.Merge(Snapshot{Favourites: []int{merged}, Compare: []int{merged}})
+				s.Favourites()
+				s.LastCompare()
+				s.Snapshot()
+			}
+		}()
+	}
+	wg.Wait()
+	s.SnapshotLastCompare()
+
+	for w := range workers {
+		if s.IsFavourite(w + 1) {
+			t.Errorf("car %d toggled an even number of times is liked", w+1)
+		}
+		if !s.IsFavourite(100 + w) {
+			t.Errorf("car %d merged is not liked", 100+w)
+		}
+	}
+	if got := len(s.Favourites()); got != workers {
+		t.Errorf("got %d favourites, want %d", got, workers)
+	}
+	if compared := s.Compared(); len(compared) != 0 {
+		t.Errorf("got %v compared after the last snapshot, want none", compared)
+	}
+	if got := len(s.History()); got > MaxHistory {
+		t.Errorf("got %d comparisons in the history, want at most %d", got, MaxHistory)
+	}
+	if changes.Load() == 0 {
+		t.Error("OnChange was never called")
+	}
+}
+
+func TestSnapshotLastCompare(t *testing.T) {
+	s := New()
+	s.ToggleCompare(3)
+	s.ToggleCompare(1)
+	if got := s.SnapshotLastCompare(); !slices.Equal(got, []int{1, 3}) {
+		t.Errorf("got %v, want [1 3]", got)
+	}
+	if s.CompareActive() {
+		t.Error("the cars are still selected after the snapshot")
+	}
+	if got := s.LastCompare(); !slices.Equal(got, []int{1, 3}) {
+		t.Errorf("got last comparison %v, want [1 3]", got)
+	}
+
+	//	A single car is not a comparison.
+	s.ToggleCompare(2)
+	s.SnapshotLastCompare()
+	if got := s.LastCompare(); !slices.Equal(got, []int{1, 3}) {
+		t.Errorf("got last comparison %v after one car, want [1 3]", got)
+	}
+}
+
+func TestToggleCompareLimit(t *testing.T) {
+	s := New()
+	for id := 1; id <= MaxCompare; id++ {
+		if _, err := s.ToggleCompare(id); err != nil {
+			t.Fatalf("selecting car %d: %v", id, err)
+		}
+	}
+	if _, err := s.ToggleCompare(MaxCompare + 1); !errors.Is(err, ErrCompareFull) {
+		t.Errorf("got %v selecting one car too many, want ErrCompareFull", err)
+	}
+	if got := len(s.Compared()); got != MaxCompare {
+		t.Errorf("got %d cars selected, want %d", got, MaxCompare)
+	}
+
+	//	A full selection can still be changed by unselecting a car first.
+	if selected, err := s.ToggleCompare(1); selected || err != nil {
+		t.Errorf("unselecting car 1: got %v, %v", selected, err)
+	}
+	if selected, err := s.ToggleCompare(MaxCompare + 1); !selected || err != nil {
+		t.Errorf("selecting car %d after unselecting one: got %v, %v", MaxCompare+1, selected, err)
+	}
+}
+
+func TestSnapshotDoesNotShareState(t *testing.T) {
+	s := New()
+	s.ToggleFavourite(1)
+	s.SetFilters(Filters{Manufacturers: []int{2}})
+
+	snapshot := s.Snapshot()
+	snapshot.Favourites[0] = 9
+	snapshot.Filters.Manufacturers[0] = 9
+
+	if !s.IsFavourite(1) || s.IsFavourite(9) {
+		t.Errorf("changing the snapshot changed the favourites: %v", s.Favourites())
+	}
+	if got := s.Filters().Manufacturers; !slices.Equal(got, []int{2}) {
+		t.Errorf("changing the snapshot changed the filters: %v", got)
+	}
+}
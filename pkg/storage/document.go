@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// writeDelay groups the changes that happen close together in one write.
+const writeDelay = 500 * time.Millisecond
+
+// Document is a versioned JSON document kept in a File, like the accounts or the reviews.
+// It checks the version of the file when it is loaded, and writes the changes in the background
+// with a Writer once it is started. Close must be called before the program exits, or the changes
+// of the last moments are lost. The stores that only keep their data in memory use a nil *Document:
+// its methods do nothing.
+type Document struct {
+	file   *File
+	writer *Writer
+}
+
+// Loads the document of the file at path into v, if it exists, and returns the Document to write it again.
+// The document must have a "version" field that is not newer than version. name tells what the document
+// keeps, like "reviews", in the errors. It returns false, and no error, when the file does not exist yet.
+func LoadDocument(path, name string, version int, v any) (*Document, bool, error) {
+	d := &Document{file: NewFile(path)}
+
+	var raw json.RawMessage
+	found, err := d.file.Load(&raw)
+	if err != nil {
+		return nil, false, err
+	}
+	if !found {
+		return d, false, nil
+	}
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return nil, false, fmt.Errorf("decoding %s: %w", path, err)
+	}
+	if header.Version > version {
+		return nil, false, fmt.Errorf("%s: %s file version %d is newer than the supported version %d", path, name, header.Version, version)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return nil, false, fmt.Errorf("decoding %s: %w", path, err)
+	}
+	return d, true, nil
+}
+
+// Starts writing the value returned by snapshot every time the document is marked as changed.
+// snapshot is called from a background goroutine, so it must be safe for concurrent use.
+func (d *Document) Start(snapshot func() any) {
+	if d == nil {
+		return
+	}
+	d.writer = NewWriter(d.file, writeDelay, snapshot)
+}
+
+// Signals that there are changes to write. It never blocks.
+func (d *Document) MarkChanged() {
+	if d == nil || d.writer == nil {
+		return
+	}
+	d.writer.MarkChanged()
+}
+
+// Closes the Writer of the document: see Writer.Close.
+func (d *Document) Close() error {
+	if d == nil || d.writer == nil {
+		return nil
+	}
+	return d.writer.Close()
+}
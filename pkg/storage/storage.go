@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// File keeps a value as a JSON document in one file.
+// Writes go to a temporary file that replaces the old one with a rename, so a crash
+// in the middle of a write leaves either the old or the new document, never a corrupted one.
+type File struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// Creates a File for the path given. The directory is created when the first document is written.
+func NewFile(path string) *File {
+	return &File{Path: path}
+}
+
+// Decodes the document into v. It returns false, and no error, when the file does not exist yet.
+func (f *File) Load(v any) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, err := os.ReadFile(f.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("decoding %s: %w", f.Path, err)
+	}
+	return true, nil
+}
+
+// Encodes v and replaces the document with it atomically.
+func (f *File) Save(v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dir := filepath.Dir(f.Path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	//	The temporary file must be in the same directory, so the rename does not cross file systems.
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(f.Path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	//	Make sure the data is on disk before the file becomes visible under its final name.
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), f.Path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// Flushes the directory entry of a rename to disk.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Writer saves a value to a File in the background every time it is marked as changed.
+// Changes that happen close together are grouped in one write.
+type Writer struct {
+	file     *File
+	delay    time.Duration
+	snapshot func() any
+
+	changed chan struct{}
+	stop    chan struct{}
+	stopped sync.WaitGroup
+}
+
+// Creates a Writer and starts it. snapshot returns the value to write; it is called
+// from the background goroutine, so it must be safe for concurrent use.
+func NewWriter(file *File, delay time.Duration, snapshot func() any) *Writer {
+	w := &Writer{
+		file:     file,
+		delay:    delay,
+		snapshot: snapshot,
+		changed:  make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+	}
+	w.stopped.Add(1)
+	go w.loop()
+	return w
+}
+
+// Signals that there are changes to write. It never blocks.
+func (w *Writer) MarkChanged() {
+	select {
+	case w.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Writes the value now.
+func (w *Writer) Flush() error {
+	return w.file.Save(w.snapshot())
+}
+
+// Stops writing in the background and writes the last changes.
+func (w *Writer) Close() error {
+	close(w.stop)
+	w.stopped.Wait()
+	return w.Flush()
+}
+
+// Writes the changes, at most once every delay.
+func (w *Writer) loop() {
+	defer w.stopped.Done()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-w.changed:
+		}
+
+		select {
+		case <-w.stop:
+			return
+		case <-time.After(w.delay):
+		}
+
+		if err := w.Flush(); err != nil {
+			log.Printf("Error saving %s: %v", w.file.Path, err)
+		}
+	}
+}
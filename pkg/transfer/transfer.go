@@ -0,0 +1,166 @@
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"cars/pkg/models"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Document has the favourites and the collections of a user, as they are exported and imported.
+type Document struct {
+	Favourites  []models.ExtendedCard `json:"favourites"`
+	Collections []Collection          `json:"collections,omitempty"`
+}
+
+// Collection is a collection of favourite cars in a Document.
+type Collection struct {
+	Name string                `json:"name"`
+	Cars []models.ExtendedCard `json:"cars"`
+}
+
+// csvHeader are the columns of the CSV files. Every favourite has a row with an empty collection,
+// and every car of a collection has one more row with the name of the collection.
+var csvHeader = []string{
+	"collection", "id", "name", "manufacturer", "country", "foundingYear", "category",
+	"year", "engine", "horsePower", "transmission", "driveTrain", "image",
+}
+
+// Writes the document as indented JSON.
+func WriteJSON(w io.Writer, doc Document) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
+
+// Writes the document as CSV, with a header row.
+func WriteCSV(w io.Writer, doc Document) error {
+	writer := csv.NewWriter(w)
+	writer.Write(csvHeader)
+	for _, card := range doc.Favourites {
+		writer.Write(csvRow("", card))
+	}
+	for _, collection := range doc.Collections {
+		for _, card := range collection.Cars {
+			writer.Write(csvRow(collection.Name, card))
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func csvRow(collection string, card models.ExtendedCard) []string {
+	return []string{
+		collection, strconv.Itoa(card.Id), card.Name, card.Manufacturer, card.Country,
+		strconv.Itoa(card.FoundingYear), card.Category, strconv.Itoa(card.Year), card.Engine,
+		strconv.Itoa(card.Horsepower), card.Transmission, card.DriveTrain, card.Image,
+	}
+}
+
+// Reads a document written by WriteJSON or WriteCSV. The format is found from the content.
+// Only the ID and the name of the cars are read: the rest comes from the catalog.
+func Read(r io.Reader) (Document, error) {
+	reader := bufio.NewReader(r)
+	first, err := firstNonSpace(reader)
+	if err != nil {
+		return Document{}, err
+	}
+	if first == '{' {
+		return readJSON(reader)
+	}
+	return readCSV(reader)
+}
+
+// Returns the first byte that is not a space, without consuming it.
+// The byte order mark that some spreadsheets write at the start is skipped.
+func firstNonSpace(reader *bufio.Reader) (byte, error) {
+	if bom, _ := reader.Peek(3); bytes.Equal(bom, []byte{0xEF, 0xBB, 0xBF}) {
+		reader.Discard(3)
+	}
+	for {
+		b, err := reader.ReadByte()
+		if err == io.EOF {
+			return 0, errors.New("the file is empty")
+		}
+		if err != nil {
+			return 0, err
+		}
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			reader.UnreadByte()
+			return b, nil
+		}
+	}
+}
+
+func readJSON(r io.Reader) (Document, error) {
+	var doc Document
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return Document{}, fmt.Errorf("the JSON file is not valid: %w", err)
+	}
+	return doc, nil
+}
+
+func readCSV(r io.Reader) (Document, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return Document{}, fmt.Errorf("the CSV file is not valid: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+	idColumn := slices.Index(header, "id")
+	if idColumn == -1 {
+		return Document{}, errors.New("the CSV file has no id column")
+	}
+	nameColumn := slices.Index(header, "name")
+	collectionColumn := slices.Index(header, "collection")
+
+	var doc Document
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Document{}, fmt.Errorf("the CSV file is not valid: %w", err)
+		}
+
+		value := column(record, idColumn)
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return Document{}, fmt.Errorf("line %d: %q is not a car ID", line, value)
+		}
+		card := models.ExtendedCard{Id: id, Name: column(record, nameColumn)}
+
+		name := column(record, collectionColumn)
+		if name == "" {
+			doc.Favourites = append(doc.Favourites, card)
+			continue
+		}
+		i := slices.IndexFunc(doc.Collections, func(c Collection) bool { return c.Name == name })
+		if i == -1 {
+			doc.Collections = append(doc.Collections, Collection{Name: name})
+			i = len(doc.Collections) - 1
+		}
+		doc.Collections[i].Cars = append(doc.Collections[i].Cars, card)
+	}
+	return doc, nil
+}
+
+// Returns the trimmed value of the column, or "" when the record does not have it.
+func column(record []string, i int) string {
+	if i < 0 || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
@@ -0,0 +1,111 @@
+package transfer
+
+import (
+	"bytes"
+	"cars/pkg/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const bom = "\xEF\xBB\xBF"
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Document
+	}{
+		{
+			"json",
+			`{"favourites": [{"id": 1, "name": "Corolla"}], "collections": [{"name": "Trucks", "cars": [{"id": 6}]}]}`,
+			Document{
+				Favourites:  []models.ExtendedCard{{Id: 1, Name: "Corolla"}},
+				Collections: []Collection{{Name: "Trucks", Cars: []models.ExtendedCard{{Id: 6}}}},
+			},
+		},
+		{
+			"json with byte order mark and spaces",
+			bom + "\r\n  " + `{"favourites": [{"id": 2}]}`,
+			Document{Favourites: []models.ExtendedCard{{Id: 2}}},
+		},
+		{
+			"csv",
+			"collection,id,name\n,1,Corolla\nTrucks,6,F-150\nTrucks,7,Silverado\n",
+			Document{
+				Favourites:  []models.ExtendedCard{{Id: 1, Name: "Corolla"}},
+				Collections: []Collection{{Name: "Trucks", Cars: []models.ExtendedCard{{Id: 6, Name: "F-150"}, {Id: 7, Name: "Silverado"}}}},
+			},
+		},
+		{
+			"csv with byte order mark",
+			bom + "id,name\n3,Model 3\n",
+			Document{Favourites: []models.ExtendedCard{{Id: 3, Name: "Model 3"}}},
+		},
+		{
+			"csv with other columns in any order and case",
+			" Name ,Year,ID\nCorolla,2023, 1 \n",
+			Document{Favourites: []models.ExtendedCard{{Id: 1, Name: "Corolla"}}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Read(strings.NewReader(test.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"empty", "", "the file is empty"},
+		{"only a byte order mark and spaces", bom + " \n", "the file is empty"},
+		{"invalid json", `{"favourites": [`, "the JSON file is not valid"},
+		{"csv without id column", "name\nCorolla\n", "the CSV file has no id column"},
+		{"csv with a wrong id", "id,name\n1,Corolla\nx,Camry\n", `line 3: "x" is not a car ID`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Read(strings.NewReader(test.input))
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("got error %v, want %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestWriteAndReadAgain(t *testing.T) {
+	doc := Document{
+		Favourites: []models.ExtendedCard{{Id: 1, Name: "Corolla"}, {Id: 6, Name: "F-150"}},
+		Collections: []Collection{
+			{Name: "Trucks, big ones", Cars: []models.ExtendedCard{{Id: 6, Name: "F-150"}}},
+		},
+	}
+	for name, write := range map[string]func(*bytes.Buffer, Document) error{
+		"json": func(b *bytes.Buffer, doc Document) error { return WriteJSON(b, doc) },
+		"csv":  func(b *bytes.Buffer, doc Document) error { return WriteCSV(b, doc) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := write(&b, doc); err != nil {
+				t.Fatal(err)
+			}
+			got, err := Read(&b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, doc) {
+				t.Errorf("got %+v, want %+v", got, doc)
+			}
+		})
+	}
+}